@@ -1,69 +1,327 @@
 package args
 
 /**
- * 从[环境变量]或[命令行参数]中获取配置
+ * 从多个来源中获取配置，优先级从高到低：
+ * [命令行参数] > [环境变量] > [.env文件] > [配置文件] > [Register声明的默认值]
  * key不区分大小写，value区分大小写
- * [命令行参数]会覆盖掉[环境变量]中的配置
+ *
+ * .env文件的路径由env_file参数指定，默认对象还会读取当前目录下的.env，不存在时忽略
+ * 配置文件的路径由config参数指定，如：--config=/etc/app.yaml，支持json、yaml、toml
+ *
+ * 命令行参数格式：
+ * key=value | -key=value | --key=value  在第一个"="处分割，value中可以包含"="
+ * --key value | -key value              下一个参数不以"-"开头且不包含"="时作为value
+ * --verbose                             没有value时为"true"
+ * --                                    之后的参数都作为位置参数
+ * 同一个key出现多次时，value会被收集为列表，Get获取最后一个，GetList获取全部
+ *
+ * 设置环境变量前缀后，只读取带前缀的环境变量，前缀不区分大小写
+ * 去掉前缀后"__"转换为"."，如：前缀为"MYAPP_"时，MYAPP_LOG_PATH -> log_path，MYAPP_DB__HOST -> db.host
+ * 默认对象的前缀在init时从环境变量ARGS_ENV_PREFIX中读取，在logger等模块的init读取参数之前生效
+ *
+ * 包级别的函数使用默认的Args对象，默认对象在init时从os.Environ()和os.Args中解析
+ * 测试时可以通过New构造Args对象，并通过SetDefault替换默认对象
  */
 
 import (
+	"fmt"
 	"os"
 	"strings"
+	"sync"
 )
 
+//参数来源
+const (
+	SourceOverride = "override" //Set或Override设置的值
+	SourceArgs     = "args"     //命令行参数
+	SourceEnv      = "env"      //环境变量
+	SourceDotEnv   = "dotenv"   //.env文件
+	SourceConfig   = "config"   //配置文件
+	SourceDefault  = "default"  //Register声明的默认值
+)
+
+const defaultDotEnvPath = ".env"
+
+//默认对象的环境变量前缀，如：ARGS_ENV_PREFIX=MYAPP_
+const EnvPrefixEnv = "ARGS_ENV_PREFIX"
+
+type entry struct {
+	values []string
+	source string
+}
+
+type Args struct {
+	lock       *sync.RWMutex
+	argMap     map[string]*entry
+	paramMap   map[string]*Param
+	envPrefix  string          //环境变量前缀
+	cmdKeys    map[string]bool //[命令行参数]和带前缀的[环境变量]中出现的key
+	positional []string        //位置参数
+	help       bool            //[命令行参数]中是否有-h或--help
+	loadErrs   []error         //读取.env文件和配置文件时的错误
+	dotEnv     bool            //未指定env_file时是否读取当前目录下的.env
+}
+
 var (
-	argMap = make(map[string]string)
+	defaultArgs     *Args
+	defaultArgsLock = new(sync.RWMutex)
 )
 
-func argUnMarshal(arg string) (string, string, bool) {
-	kv := strings.Split(arg, "=")
-	if len(kv) != 2 {
-		return "", "", false
+func init() {
+	defaultArgs = New(os.Environ(), os.Args[1:], WithEnvPrefix(os.Getenv(EnvPrefixEnv)), WithDefaultDotEnv())
+}
+
+//获取默认的Args对象
+func Default() *Args {
+	defaultArgsLock.RLock()
+	defer defaultArgsLock.RUnlock()
+	return defaultArgs
+}
+
+//替换默认的Args对象，返回被替换的对象，一般用于测试
+func SetDefault(a *Args) *Args {
+	defaultArgsLock.Lock()
+	defer defaultArgsLock.Unlock()
+	rtn := defaultArgs
+	defaultArgs = a
+	return rtn
+}
+
+//去掉value两端成对的引号
+func unquote(value string) string {
+	if len(value) >= 2 {
+		if (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+//去掉key前面的"-"或"--"
+func trimDash(key string) string {
+	if strings.HasPrefix(key, "--") {
+		return key[2:]
+	}
+	return strings.TrimPrefix(key, "-")
+}
+
+//是否为负数，如：-1、-0.5
+func isNegativeNumber(arg string) bool {
+	return len(arg) > 1 && arg[0] == '-' && (arg[1] == '.' || (arg[1] >= '0' && arg[1] <= '9'))
+}
+
+//参数是否可以作为上一个"--key"的value
+func isValue(arg string) bool {
+	if isNegativeNumber(arg) {
+		return true
+	}
+	return !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=")
+}
+
+//解析命令行参数，argv中不包含程序名
+//返回kv列表、位置参数以及是否有-h或--help
+func argUnMarshal(argv []string) (map[string][]string, []string, bool) {
+	kvs := make(map[string][]string)
+	rest := make([]string, 0)
+	help := false
+
+	for i := 0; i < len(argv); i++ {
+		arg := argv[i]
+
+		//"--"之后都是位置参数
+		if arg == "--" {
+			rest = append(rest, argv[i+1:]...)
+			break
+		}
+
+		isOption := len(arg) > 1 && arg[0] == '-' && !isNegativeNumber(arg)
+		index := strings.Index(arg, "=")
+
+		var key, value string
+		switch {
+		case index > 0:
+			key, value = arg[:index], unquote(arg[index+1:])
+			if isOption {
+				key = trimDash(key)
+			}
+		case isOption:
+			key = trimDash(arg)
+			if key == "h" || key == "help" {
+				help = true
+				continue
+			}
+			if i+1 < len(argv) && isValue(argv[i+1]) {
+				value = unquote(argv[i+1])
+				i++
+			} else {
+				value = "true"
+			}
+		default:
+			rest = append(rest, arg)
+			continue
+		}
+
+		if key = strings.ToLower(key); key == "" {
+			continue
+		}
+		kvs[key] = append(kvs[key], value)
 	}
 
-	key := kv[0]
+	return kvs, rest, help
+}
 
-	if len(key) > 3 && key[0:2] == "--" {
-		key = key[2:]
-	} else if len(key) > 2 && key[0:1] == "-" {
-		key = key[1:]
+//环境变量名转换为key
+//prefix不为空时，只接受带前缀的环境变量，去掉前缀后"__"转换为"."
+func envKey(name string, prefix string) (string, bool) {
+	if prefix != "" {
+		if len(name) <= len(prefix) || !strings.EqualFold(name[:len(prefix)], prefix) {
+			return "", false
+		}
+		name = strings.ReplaceAll(name[len(prefix):], "__", ".")
 	}
+	return strings.ToLower(name), true
+}
 
-	if len(key) == 0 {
+//解析环境变量，在第一个"="处分割
+func envUnMarshal(env string, prefix string) (string, string, bool) {
+	index := strings.Index(env, "=")
+	if index <= 0 {
 		return "", "", false
 	}
+	key, ok := envKey(env[:index], prefix)
+	if !ok {
+		return "", "", false
+	}
+	return key, env[index+1:], true
+}
 
-	key = strings.ToLower(key)
+//按优先级从多个kv列表中查找key
+func lookup(key string, layers ...map[string][]string) (string, bool) {
+	for _, layer := range layers {
+		if values, ok := layer[key]; ok && len(values) != 0 {
+			return values[len(values)-1], true
+		}
+	}
+	return "", false
+}
 
-	value := kv[1]
+type Option func(*Args)
 
-	return key, value, true
+//设置环境变量前缀，如："MYAPP_"，只读取带前缀的环境变量和.env文件中的变量
+func WithEnvPrefix(prefix string) Option {
+	return func(a *Args) {
+		a.envPrefix = prefix
+	}
 }
 
-func envUnMarshal(env string) (string, string) {
-	kv := strings.Split(env, "=")
-	return strings.ToLower(kv[0]), kv[1]
+//未指定env_file时读取当前目录下的.env，默认对象使用此选项
+func WithDefaultDotEnv() Option {
+	return func(a *Args) {
+		a.dotEnv = true
+	}
 }
 
-func init() {
+//新建Args对象
+//env为"KEY=VALUE"格式的环境变量，如：os.Environ()
+//argv为命令行参数，不包含程序名，如：os.Args[1:]
+//只读取env_file指定的.env文件，不读取当前目录下的.env，除非使用WithDefaultDotEnv
+func New(env []string, argv []string, opts ...Option) *Args {
+	rtn := &Args{
+		lock:       new(sync.RWMutex),
+		argMap:     make(map[string]*entry),
+		paramMap:   make(map[string]*Param),
+		cmdKeys:    make(map[string]bool),
+		positional: make([]string, 0),
+		loadErrs:   make([]error, 0),
+	}
+	for _, opt := range opts {
+		opt(rtn)
+	}
+	rtn.Register(builtinParams...)
+
 	//解析环境变量
-	for _, env := range os.Environ() {
-		k, v := envUnMarshal(env)
-		argMap[k] = v
+	envs := make(map[string][]string)
+	for _, e := range env {
+		if k, v, ok := envUnMarshal(e, rtn.envPrefix); ok {
+			envs[k] = []string{v}
+			//带前缀的环境变量一定属于本程序，需要检查key
+			if rtn.envPrefix != "" {
+				rtn.cmdKeys[k] = true
+			}
+		}
 	}
 
 	//解析命令行参数
-	for _, arg := range os.Args {
-		if k, v, ok := argUnMarshal(arg); ok {
-			argMap[k] = v
+	kvs, rest, help := argUnMarshal(argv)
+	for k := range kvs {
+		rtn.cmdKeys[k] = true
+	}
+	rtn.positional = rest
+	rtn.help = help
+
+	//解析.env文件，未指定env_file且默认文件不存在时忽略
+	dotEnvs := make(map[string][]string)
+	dotEnvPath, explicit := lookup("env_file", kvs, envs)
+	if !explicit && rtn.dotEnv {
+		dotEnvPath = defaultDotEnvPath
+	}
+	if dotEnvPath != "" {
+		if values, err := loadDotEnv(dotEnvPath); err == nil {
+			for k, v := range values {
+				if key, ok := envKey(k, rtn.envPrefix); ok {
+					dotEnvs[key] = []string{v}
+				}
+			}
+		} else if explicit || !os.IsNotExist(err) {
+			rtn.loadErrs = append(rtn.loadErrs, fmt.Errorf("load env_file: %s", err.Error()))
+		}
+	}
+
+	//解析配置文件
+	configs := make(map[string][]string)
+	if configPath, ok := lookup("config", kvs, envs, dotEnvs); ok {
+		if values, err := loadConfigFile(configPath); err == nil {
+			configs = values
+		} else {
+			rtn.loadErrs = append(rtn.loadErrs, fmt.Errorf("load config: %s", err.Error()))
 		}
 	}
+
+	//按优先级从低到高合并
+	rtn.merge(configs, SourceConfig)
+	rtn.merge(dotEnvs, SourceDotEnv)
+	rtn.merge(envs, SourceEnv)
+	rtn.merge(kvs, SourceArgs)
+
+	return rtn
 }
 
-//获取参数，如果失败则返回默认值
-func GetOrDefault(key string, defaultValue string) string {
+//合并kv列表，已存在的key会被覆盖
+func (a *Args) merge(kvs map[string][]string, source string) {
+	for k, v := range kvs {
+		a.argMap[k] = &entry{values: v, source: source}
+	}
+}
+
+//按优先级查找key，都不存在时使用Register声明的默认值
+func (a *Args) getEntry(key string) (*entry, bool) {
 	key = strings.ToLower(key)
-	if value, ok := argMap[key]; ok {
+
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	if v, ok := a.argMap[key]; ok && len(v.values) != 0 {
+		return v, true
+	}
+	if param, ok := a.paramMap[key]; ok && param.Default != "" {
+		return &entry{values: []string{param.Default}, source: SourceDefault}, true
+	}
+	return nil, false
+}
+
+//获取参数，如果失败则返回默认值
+func (a *Args) GetOrDefault(key string, defaultValue string) string {
+	if value, ok := a.Get(key); ok {
 		return value
 	} else {
 		return defaultValue
@@ -71,11 +329,135 @@ func GetOrDefault(key string, defaultValue string) string {
 }
 
 //获取参数，成功返回(value, true)，失败返回("", false)
-func Get(key string) (string, bool) {
-	key = strings.ToLower(key)
-	if value, ok := argMap[key]; ok {
-		return value, true
+//key出现多次时返回最后一个value
+func (a *Args) Get(key string) (string, bool) {
+	if v, ok := a.getEntry(key); ok {
+		return v.values[len(v.values)-1], true
 	} else {
 		return "", false
 	}
 }
+
+//获取参数的所有value，成功返回(values, true)，失败返回(nil, false)
+func (a *Args) GetList(key string) ([]string, bool) {
+	if v, ok := a.getEntry(key); ok {
+		rtn := make([]string, len(v.values))
+		copy(rtn, v.values)
+		return rtn, true
+	} else {
+		return nil, false
+	}
+}
+
+//获取参数的来源，如：SourceArgs，参数不存在时返回""
+func (a *Args) Source(key string) string {
+	if v, ok := a.getEntry(key); ok {
+		return v.source
+	}
+	return ""
+}
+
+//获取位置参数，即不是key=value格式的参数以及"--"之后的参数
+func (a *Args) Positional() []string {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	rtn := make([]string, len(a.positional))
+	copy(rtn, a.positional)
+	return rtn
+}
+
+//设置参数，优先级最高
+func (a *Args) Set(key string, values ...string) {
+	key = strings.ToLower(key)
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.set(key, values)
+}
+
+//调用方需要持有写锁
+func (a *Args) set(key string, values []string) {
+	if len(values) == 0 {
+		delete(a.argMap, key)
+		return
+	}
+	a.argMap[key] = &entry{values: append([]string(nil), values...), source: SourceOverride}
+}
+
+//临时设置参数，调用返回的函数恢复原来的值，一般用于测试
+//  restore := args.Override("env", "prod")
+//  defer restore()
+func (a *Args) Override(key string, values ...string) func() {
+	key = strings.ToLower(key)
+
+	a.lock.Lock()
+	old, exists := a.argMap[key]
+	a.set(key, values)
+	a.lock.Unlock()
+
+	return func() {
+		a.lock.Lock()
+		defer a.lock.Unlock()
+		if exists {
+			a.argMap[key] = old
+		} else {
+			delete(a.argMap, key)
+		}
+	}
+}
+
+//设置环境变量前缀，如："MYAPP_"，会重新解析默认的Args对象
+//已声明的参数以及Set设置的参数会被保留
+//注意：logger等模块在init时已经读取了参数，不会重新读取，需要在init之前生效时使用环境变量ARGS_ENV_PREFIX
+func SetEnvPrefix(prefix string) {
+	old := Default()
+	rtn := New(os.Environ(), os.Args[1:], WithEnvPrefix(prefix), WithDefaultDotEnv())
+	rtn.Register(old.Params()...)
+
+	old.lock.RLock()
+	for k, v := range old.argMap {
+		if v.source == SourceOverride {
+			rtn.argMap[k] = v
+		}
+	}
+	old.lock.RUnlock()
+
+	SetDefault(rtn)
+}
+
+//获取参数，如果失败则返回默认值
+func GetOrDefault(key string, defaultValue string) string {
+	return Default().GetOrDefault(key, defaultValue)
+}
+
+//获取参数，成功返回(value, true)，失败返回("", false)
+//key出现多次时返回最后一个value
+func Get(key string) (string, bool) {
+	return Default().Get(key)
+}
+
+//获取参数的所有value，成功返回(values, true)，失败返回(nil, false)
+func GetList(key string) ([]string, bool) {
+	return Default().GetList(key)
+}
+
+//获取参数的来源，如：SourceArgs，参数不存在时返回""
+func Source(key string) string {
+	return Default().Source(key)
+}
+
+//获取位置参数，即不是key=value格式的参数以及"--"之后的参数
+func Positional() []string {
+	return Default().Positional()
+}
+
+//设置参数，优先级最高
+func Set(key string, values ...string) {
+	Default().Set(key, values...)
+}
+
+//临时设置参数，调用返回的函数恢复原来的值，一般用于测试
+func Override(key string, values ...string) func() {
+	return Default().Override(key, values...)
+}
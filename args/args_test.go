@@ -1,6 +1,9 @@
 package args
 
 import (
+	"io/ioutil"
+	"os"
+	"reflect"
 	"testing"
 )
 
@@ -22,3 +25,232 @@ func TestGet(t *testing.T) {
 		t.Error("get default shell")
 	}
 }
+
+func TestArgUnMarshal(t *testing.T) {
+	argv := []string{
+		"dsn=user:pw@tcp(h)/db?x=1",
+		"-token=YWJj==",
+		"--Name", "demo",
+		"--verbose",
+		"--offset", "-1",
+		"--tag=a", "--tag", "b",
+		`msg="hello world"`,
+		"file.txt",
+		"--debug",
+		"--",
+		"--not_option", "x=y",
+	}
+	kvs, rest, help := argUnMarshal(argv)
+
+	expect := map[string][]string{
+		"dsn":     {"user:pw@tcp(h)/db?x=1"},
+		"token":   {"YWJj=="},
+		"name":    {"demo"},
+		"verbose": {"true"},
+		"offset":  {"-1"},
+		"tag":     {"a", "b"},
+		"msg":     {"hello world"},
+		"debug":   {"true"},
+	}
+	if !reflect.DeepEqual(kvs, expect) {
+		t.Error("kvs", kvs)
+	}
+	if !reflect.DeepEqual(rest, []string{"file.txt", "--not_option", "x=y"}) {
+		t.Error("rest", rest)
+	}
+	if help {
+		t.Error("help")
+	}
+
+	if _, _, help := argUnMarshal([]string{"-h"}); !help {
+		t.Error("-h")
+	}
+	if _, _, help := argUnMarshal([]string{"--help"}); !help {
+		t.Error("--help")
+	}
+}
+
+func TestEnvUnMarshal(t *testing.T) {
+	if k, v, ok := envUnMarshal("DSN=a=b=c", ""); !ok || k != "dsn" || v != "a=b=c" {
+		t.Error(k, v, ok)
+	}
+	if _, _, ok := envUnMarshal("=C:=C:\\", ""); ok {
+		t.Error("empty key")
+	}
+	if k, v, ok := envUnMarshal("MYAPP_DB__HOST=localhost", "myapp_"); !ok || k != "db.host" || v != "localhost" {
+		t.Error(k, v, ok)
+	}
+	if _, _, ok := envUnMarshal("ENV=prod", "MYAPP_"); ok {
+		t.Error("without prefix")
+	}
+	if _, _, ok := envUnMarshal("MYAPP_=x", "MYAPP_"); ok {
+		t.Error("empty key with prefix")
+	}
+}
+
+func TestNew(t *testing.T) {
+	a := New(
+		[]string{"ENV=prod", "LOG_PATH=/var/log/env.log", "DSN=a=b"},
+		[]string{"--log_path", "/var/log/args.log", "file.txt"},
+	)
+
+	if v, _ := a.Get("env"); v != "prod" || a.Source("env") != SourceEnv {
+		t.Error("env", v, a.Source("env"))
+	}
+	if v, _ := a.Get("log_path"); v != "/var/log/args.log" || a.Source("log_path") != SourceArgs {
+		t.Error("log_path", v, a.Source("log_path"))
+	}
+	if v, _ := a.Get("dsn"); v != "a=b" {
+		t.Error("dsn", v)
+	}
+	if !reflect.DeepEqual(a.Positional(), []string{"file.txt"}) {
+		t.Error("positional", a.Positional())
+	}
+	if _, ok := a.Get("shell"); ok {
+		t.Error("get process env")
+	}
+}
+
+func TestGetList(t *testing.T) {
+	a := New(nil, []string{"tag=a", "--tag", "b"})
+
+	if v, ok := a.Get("tag"); !ok || v != "b" {
+		t.Error("get last", v)
+	}
+	if v, ok := a.GetList("TAG"); !ok || !reflect.DeepEqual(v, []string{"a", "b"}) {
+		t.Error("get list", v)
+	}
+	if _, ok := a.GetList("none"); ok {
+		t.Error("get list none")
+	}
+}
+
+func TestSource(t *testing.T) {
+	a := New([]string{"SOURCE_ENV=a"}, []string{"source_args=b"})
+	a.Register(Param{Name: "source_default", Default: "c"})
+
+	if a.Source("source_args") != SourceArgs {
+		t.Error("source args", a.Source("source_args"))
+	}
+	if a.Source("source_env") != SourceEnv {
+		t.Error("source env", a.Source("source_env"))
+	}
+	if v, ok := a.Get("source_default"); !ok || v != "c" || a.Source("source_default") != SourceDefault {
+		t.Error("source default", v, a.Source("source_default"))
+	}
+	if a.Source("none") != "" {
+		t.Error("source none", a.Source("none"))
+	}
+}
+
+func TestOverride(t *testing.T) {
+	a := New([]string{"ENV=prod"}, nil)
+
+	restore := a.Override("env", "dev")
+	if v, _ := a.Get("env"); v != "dev" || a.Source("env") != SourceOverride {
+		t.Error("override", v, a.Source("env"))
+	}
+	restore()
+	if v, _ := a.Get("env"); v != "prod" || a.Source("env") != SourceEnv {
+		t.Error("restore", v, a.Source("env"))
+	}
+
+	restore = a.Override("override_none", "x")
+	restore()
+	if _, ok := a.Get("override_none"); ok {
+		t.Error("restore none")
+	}
+
+	a.Set("set_key", "a", "b")
+	if v, _ := a.GetList("set_key"); !reflect.DeepEqual(v, []string{"a", "b"}) {
+		t.Error("set", v)
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	old := SetDefault(New([]string{"LOG_PATH=/tmp/default.log"}, nil))
+	defer SetDefault(old)
+
+	if v := GetOrDefault("log_path", ""); v != "/tmp/default.log" {
+		t.Error("set default", v)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "args")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	config := writeTempFile(t, dir, "app.yaml", "log_path: /var/log/config.log\nenv: test\nidc: config\n")
+	dotEnv := writeTempFile(t, dir, "app.env", "CONFIG="+config+"\nENV=dotenv\n")
+	a := New([]string{"ENV_FILE=" + dotEnv}, []string{"idc=args"})
+
+	expect := map[string][2]string{
+		"log_path": {"/var/log/config.log", SourceConfig},
+		"env":      {"dotenv", SourceDotEnv},
+		"idc":      {"args", SourceArgs},
+		"env_file": {dotEnv, SourceEnv},
+	}
+	for key, e := range expect {
+		if v, _ := a.Get(key); v != e[0] || a.Source(key) != e[1] {
+			t.Error(key, v, a.Source(key))
+		}
+	}
+	if err := a.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	if err := New(nil, []string{"config=" + dir + "/none.yaml"}).Validate(); err == nil {
+		t.Error("load none config")
+	}
+}
+
+func TestWithEnvPrefix(t *testing.T) {
+	a := New(
+		[]string{"ENV=prod", "MYAPP_LOG_PATH=/var/log/app.log", "MYAPP_DB__HOST=localhost", "MYAPP_LOG_PTH=/x"},
+		nil,
+		WithEnvPrefix("MYAPP_"),
+	)
+
+	if v, _ := a.Get("env"); v != "dev" || a.Source("env") != SourceDefault {
+		t.Error("env without prefix", v, a.Source("env"))
+	}
+	if v, _ := a.Get("log_path"); v != "/var/log/app.log" {
+		t.Error("log_path", v)
+	}
+	if v, _ := a.Get("db.host"); v != "localhost" {
+		t.Error("db.host", v)
+	}
+	if !reflect.DeepEqual(a.Unknown(), []string{"db.host", "log_pth"}) {
+		t.Error("unknown", a.Unknown())
+	}
+}
+
+func TestSetEnvPrefix(t *testing.T) {
+	old := SetDefault(New(os.Environ(), nil))
+	defer SetDefault(old)
+
+	Register(Param{Name: "prefix_param"})
+	Set("prefix_set", "x")
+	SetEnvPrefix("ARGS_TEST_PREFIX_")
+
+	if _, ok := Get("shell"); ok {
+		t.Error("env without prefix")
+	}
+	if v, _ := Get("prefix_set"); v != "x" {
+		t.Error("set", v)
+	}
+	found := false
+	for _, param := range Params() {
+		if param.Name == "prefix_param" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("register")
+	}
+}
@@ -0,0 +1,149 @@
+package args
+
+/**
+ * 根据结构体tag把参数绑定到结构体中
+ *
+ * type Config struct {
+ *     Port    int           `arg:"port" default:"8080" desc:"监听端口"`
+ *     Timeout time.Duration `arg:"timeout" default:"3s"`
+ *     Brokers []string      `arg:"brokers" required:"true"`
+ * }
+ *
+ * arg：参数名，为空时使用字段名的蛇形命名，"-"表示忽略该字段
+ * default：参数不存在时使用的默认值
+ * required：为"true"时参数必须存在
+ * []string类型的参数在key出现多次时使用全部value，否则按逗号分隔
+ * desc：参数说明
+ *
+ * 绑定的字段会自动声明为参数(见Register)
+ */
+
+import (
+	"errors"
+	"fmt"
+	"github.com/vrg0/go-common/util"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+//参数值格式错误
+type FieldError struct {
+	Key   string
+	Value string
+	Err   error
+}
+
+func (fe *FieldError) Error() string {
+	return fmt.Sprintf("%s=%q: %s", fe.Key, fe.Value, fe.Err.Error())
+}
+
+//参数错误的汇总，包括缺失的参数和格式错误的参数
+type Error struct {
+	Missing    []string
+	Malformed  []*FieldError
+	LoadErrors []error //读取.env文件和配置文件时的错误
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, 0, 2+len(e.LoadErrors))
+	if len(e.Missing) != 0 {
+		parts = append(parts, "missing required args: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Malformed) != 0 {
+		malformed := make([]string, 0, len(e.Malformed))
+		for _, fe := range e.Malformed {
+			malformed = append(malformed, fe.Error())
+		}
+		parts = append(parts, "malformed args: "+strings.Join(malformed, ", "))
+	}
+	for _, err := range e.LoadErrors {
+		parts = append(parts, err.Error())
+	}
+	return "args: " + strings.Join(parts, "; ")
+}
+
+func (e *Error) empty() bool {
+	return len(e.Missing) == 0 && len(e.Malformed) == 0 && len(e.LoadErrors) == 0
+}
+
+//把参数绑定到结构体中，v必须为非nil的结构体指针
+//所有缺失和格式错误的参数都会汇总到*Error中返回
+func (a *Args) Bind(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("args: Bind requires a non-nil pointer to struct")
+	}
+
+	rtn := &Error{}
+	a.bindStruct(rv.Elem(), "", rtn)
+	if rtn.empty() {
+		return nil
+	}
+	return rtn
+}
+
+func (a *Args) bindStruct(rv reflect.Value, prefix string, bindErr *Error) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fieldValue := rv.Field(i)
+		name, inline, ok := util.FieldKey(field, field.Tag.Get("arg"))
+		if !ok {
+			continue
+		}
+
+		//嵌套的结构体，匿名结构体不增加前缀
+		if field.Type.Kind() == reflect.Struct && !util.IsTextUnmarshaler(field.Type) {
+			if inline {
+				a.bindStruct(fieldValue, prefix, bindErr)
+			} else {
+				a.bindStruct(fieldValue, prefix+name+".", bindErr)
+			}
+			continue
+		}
+
+		key := strings.ToLower(prefix + name)
+
+		//声明参数
+		defaultValue, hasDefault := field.Tag.Lookup("default")
+		required, _ := strconv.ParseBool(field.Tag.Get("required"))
+		a.Register(Param{
+			Name:     key,
+			Desc:     field.Tag.Get("desc"),
+			Type:     typeOf(field.Type),
+			Default:  defaultValue,
+			Required: required,
+		})
+
+		values, ok := a.GetList(key)
+		if !ok && hasDefault {
+			values, ok = []string{defaultValue}, true
+		}
+		if !ok {
+			if required {
+				bindErr.Missing = append(bindErr.Missing, key)
+			}
+			continue
+		}
+
+		//key出现多次时，[]string使用全部value，其他类型使用最后一个value
+		if len(values) > 1 && fieldValue.Kind() == reflect.Slice && fieldValue.Type().Elem().Kind() == reflect.String {
+			util.SetStringSlice(fieldValue, values)
+			continue
+		}
+		value := values[len(values)-1]
+		if err := util.SetValue(fieldValue, value); err != nil {
+			bindErr.Malformed = append(bindErr.Malformed, &FieldError{Key: key, Value: value, Err: err})
+		}
+	}
+}
+
+//把参数绑定到结构体中，v必须为非nil的结构体指针
+//所有缺失和格式错误的参数都会汇总到*Error中返回
+func Bind(v interface{}) error {
+	return Default().Bind(v)
+}
@@ -0,0 +1,173 @@
+package args
+
+import (
+	"github.com/vrg0/go-common/util"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type tBindDB struct {
+	Host string `arg:"host" default:"localhost"`
+	Port int    `arg:"port" default:"3306"`
+}
+
+type tBindConfig struct {
+	Name    string        `arg:"bind_name" required:"true"`
+	Port    int           `arg:"bind_port" default:"8080"`
+	Ratio   float64       `arg:"bind_ratio"`
+	Verbose bool          `arg:"bind_verbose"`
+	Timeout time.Duration `arg:"bind_timeout" default:"3s"`
+	Brokers []string      `arg:"bind_brokers"`
+	LogPath string
+	Ignore  string  `arg:"-"`
+	DB      tBindDB `arg:"bind_db"`
+}
+
+func TestBind(t *testing.T) {
+	a := New(nil, []string{
+		"bind_name=demo",
+		"bind_ratio=0.5",
+		"--bind_verbose",
+		"bind_brokers=a:9092, b:9092,",
+		"log_path=/tmp/bind.log",
+		"bind_db.host=db.local",
+		"ignore=xxx",
+	})
+
+	cfg := tBindConfig{}
+	if err := a.Bind(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := tBindConfig{
+		Name:    "demo",
+		Port:    8080,
+		Ratio:   0.5,
+		Verbose: true,
+		Timeout: time.Second * 3,
+		Brokers: []string{"a:9092", "b:9092"},
+		LogPath: "/tmp/bind.log",
+		DB:      tBindDB{Host: "db.local", Port: 3306},
+	}
+	if !reflect.DeepEqual(cfg, expect) {
+		t.Errorf("bind %+v, expect %+v", cfg, expect)
+	}
+
+	//绑定的字段会自动声明
+	found := false
+	for _, param := range a.Params() {
+		if param.Name == "bind_port" && param.Type == TypeInt && param.Default == "8080" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("bind register")
+	}
+}
+
+func TestBindError(t *testing.T) {
+	a := New(nil, []string{"bind_port=http", "bind_timeout=3"})
+
+	cfg := tBindConfig{}
+	err := a.Bind(&cfg)
+	bindErr, ok := err.(*Error)
+	if !ok {
+		t.Fatal("bind error type", err)
+	}
+	if !reflect.DeepEqual(bindErr.Missing, []string{"bind_name"}) {
+		t.Error("missing", bindErr.Missing)
+	}
+	if len(bindErr.Malformed) != 2 || bindErr.Malformed[0].Key != "bind_port" || bindErr.Malformed[1].Key != "bind_timeout" {
+		t.Error("malformed", bindErr.Malformed)
+	}
+	t.Log(err)
+
+	if a.Bind(cfg) == nil {
+		t.Error("bind non-pointer")
+	}
+}
+
+func TestBindList(t *testing.T) {
+	a := New(nil, []string{"bind_name=demo", "bind_brokers=a:9092", "bind_brokers=b:9092,c:9092"})
+
+	cfg := tBindConfig{}
+	if err := a.Bind(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg.Brokers, []string{"a:9092", "b:9092,c:9092"}) {
+		t.Error("brokers", cfg.Brokers)
+	}
+}
+
+func TestBindInt(t *testing.T) {
+	a := New(nil, []string{"bind_name=demo", "bind_port=010", "bind_db.port=0x10"})
+
+	cfg := tBindConfig{}
+	if err := a.Bind(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	//整数按十进制解析，"0x"开头时为十六进制
+	if cfg.Port != 10 || cfg.DB.Port != 16 {
+		t.Error("port", cfg.Port, cfg.DB.Port)
+	}
+}
+
+type tRegion string
+
+func TestBindNamedSlice(t *testing.T) {
+	a := New(nil, []string{"regions=bj, sh", "zones=a", "zones=b"})
+
+	cfg := struct {
+		Regions []tRegion
+		Zones   []tRegion
+	}{}
+	if err := a.Bind(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg.Regions, []tRegion{"bj", "sh"}) {
+		t.Error("regions", cfg.Regions)
+	}
+	//key出现多次时使用全部value
+	if !reflect.DeepEqual(cfg.Zones, []tRegion{"a", "b"}) {
+		t.Error("zones", cfg.Zones)
+	}
+}
+
+type tBindBase struct {
+	LogPath    string
+	RetryTimes int
+}
+
+//没有tag的字段使用蛇形命名，匿名结构体展开到上一层，和conf.Unmarshal相同
+func TestBindFieldKey(t *testing.T) {
+	a := New(nil, []string{"log_path=/tmp/x.log", "retry_times=3", "read_timeout=1s", "db.host_name=db.local"})
+
+	cfg := struct {
+		tBindBase
+		ReadTimeout time.Duration
+		DB          struct {
+			HostName string
+		}
+	}{}
+	if err := a.Bind(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LogPath != "/tmp/x.log" || cfg.RetryTimes != 3 || cfg.ReadTimeout != time.Second || cfg.DB.HostName != "db.local" {
+		t.Errorf("bind %+v", cfg)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"LogPath":  "log_path",
+		"HTTPPort": "http_port",
+		"Env":      "env",
+		"AppID":    "app_id",
+	}
+	for in, out := range cases {
+		if util.SnakeCase(in) != out {
+			t.Error(in, util.SnakeCase(in))
+		}
+	}
+}
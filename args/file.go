@@ -0,0 +1,182 @@
+package args
+
+/**
+ * 从文件中读取参数
+ *
+ * .env文件：每行一个KEY=VALUE，支持"export "前缀、"#"注释以及单引号/双引号包围的value
+ * 配置文件：根据扩展名解析.json、.yaml、.yml、.toml，嵌套的key使用"."连接，如：db.host
+ */
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//解析.env文件
+func loadDotEnv(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	rtn := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		index := strings.Index(line, "=")
+		if index <= 0 {
+			return nil, fmt.Errorf("%s:%d: invalid line %q", path, lineNum, line)
+		}
+		key := strings.TrimSpace(line[:index])
+		value, err := dotEnvValue(strings.TrimSpace(line[index+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", path, lineNum, err.Error())
+		}
+		rtn[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rtn, nil
+}
+
+//解析.env文件中的value
+//双引号中支持转义字符，单引号中原样保留，没有引号时"#"之后为注释
+func dotEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch value[0] {
+	case '"':
+		end := strings.LastIndex(value, `"`)
+		if end == 0 {
+			return "", fmt.Errorf("unterminated quote %s", value)
+		}
+		return strconv.Unquote(value[:end+1])
+	case '\'':
+		end := strings.LastIndex(value, "'")
+		if end == 0 {
+			return "", fmt.Errorf("unterminated quote %s", value)
+		}
+		return value[1:end], nil
+	default:
+		if index := strings.Index(value, " #"); index != -1 {
+			value = value[:index]
+		}
+		return strings.TrimSpace(value), nil
+	}
+}
+
+//解析配置文件，根据扩展名选择格式
+func loadConfigFile(path string) (map[string][]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &tree)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		m := make(map[string]interface{})
+		_, err = toml.Decode(string(data), &m)
+		tree = m
+	default:
+		return nil, fmt.Errorf("%s: unsupported config file format", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err.Error())
+	}
+
+	rtn := make(map[string][]string)
+	flatten("", tree, rtn)
+	return rtn, nil
+}
+
+//把嵌套的配置展开成"a.b.c"格式的key
+//元素为标量的数组展开成列表，其他数组使用下标作为key，如：servers.0.host
+func flatten(prefix string, node interface{}, rtn map[string][]string) {
+	join := func(key string) string {
+		key = strings.ToLower(key)
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for k, v := range n {
+			flatten(join(k), v, rtn)
+		}
+	case map[interface{}]interface{}:
+		for k, v := range n {
+			flatten(join(fmt.Sprint(k)), v, rtn)
+		}
+	case []interface{}:
+		if isScalarList(n) {
+			values := make([]string, 0, len(n))
+			for _, v := range n {
+				values = append(values, scalarString(v))
+			}
+			rtn[prefix] = values
+			return
+		}
+		for i, v := range n {
+			flatten(join(strconv.Itoa(i)), v, rtn)
+		}
+	case []map[string]interface{}:
+		for i, v := range n {
+			flatten(join(strconv.Itoa(i)), v, rtn)
+		}
+	case nil:
+		return
+	default:
+		if prefix != "" {
+			rtn[prefix] = []string{scalarString(n)}
+		}
+	}
+}
+
+func isScalarList(list []interface{}) bool {
+	for _, v := range list {
+		switch v.(type) {
+		case map[string]interface{}, map[interface{}]interface{}, []interface{}:
+			return false
+		}
+	}
+	return true
+}
+
+func scalarString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	default:
+		return fmt.Sprint(s)
+	}
+}
+
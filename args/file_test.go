@@ -0,0 +1,141 @@
+package args
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTempFile(t *testing.T, dir string, name string, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "args")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	path := writeTempFile(t, dir, ".env", `
+# comment
+LOG_PATH=/var/log/app.log
+export ENV=prod
+DSN="user:pw@tcp(h)/db?x=1\n"
+RAW='a #b'
+NAME=demo # comment
+EMPTY=
+`)
+	values, err := loadDotEnv(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]string{
+		"LOG_PATH": "/var/log/app.log",
+		"ENV":      "prod",
+		"DSN":      "user:pw@tcp(h)/db?x=1\n",
+		"RAW":      "a #b",
+		"NAME":     "demo",
+		"EMPTY":    "",
+	}
+	if !reflect.DeepEqual(values, expect) {
+		t.Error(values)
+	}
+
+	bad := writeTempFile(t, dir, "bad.env", "NAME\n")
+	if _, err := loadDotEnv(bad); err == nil {
+		t.Error("load bad .env")
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "args")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	expect := map[string][]string{
+		"log_path":       {"/var/log/app.log"},
+		"db.host":        {"localhost"},
+		"db.port":        {"3306"},
+		"brokers":        {"a:9092", "b:9092"},
+		"servers.0.name": {"s1"},
+	}
+	files := map[string]string{
+		"app.json": `{"LOG_PATH": "/var/log/app.log", "db": {"host": "localhost", "port": 3306},
+			"brokers": ["a:9092", "b:9092"], "servers": [{"name": "s1"}]}`,
+		"app.yaml": `
+log_path: /var/log/app.log
+db:
+  host: localhost
+  port: 3306
+brokers: [a:9092, b:9092]
+servers:
+  - name: s1
+`,
+		"app.toml": `
+log_path = "/var/log/app.log"
+brokers = ["a:9092", "b:9092"]
+[db]
+host = "localhost"
+port = 3306
+[[servers]]
+name = "s1"
+`,
+	}
+	for name, content := range files {
+		values, err := loadConfigFile(writeTempFile(t, dir, name, content))
+		if err != nil {
+			t.Error(name, err)
+			continue
+		}
+		if !reflect.DeepEqual(values, expect) {
+			t.Error(name, values)
+		}
+	}
+
+	if _, err := loadConfigFile(writeTempFile(t, dir, "app.ini", "a=b")); err == nil {
+		t.Error("load unsupported format")
+	}
+}
+
+func TestDefaultDotEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "args")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	writeTempFile(t, dir, ".env", "DOTENV_NAME=abc\n")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.Chdir(wd)
+	}()
+
+	//New不读取当前目录下的.env
+	if v, ok := New(nil, nil).Get("dotenv_name"); ok {
+		t.Error("New read .env", v)
+	}
+	if v, _ := New(nil, nil, WithDefaultDotEnv()).Get("dotenv_name"); v != "abc" {
+		t.Error("WithDefaultDotEnv", v)
+	}
+}
@@ -0,0 +1,248 @@
+package args
+
+/**
+ * 参数声明
+ * 声明过的参数会出现在--help的输出中，并且会在Validate时检查是否缺失和格式是否正确
+ * [命令行参数]中未声明的key会被当作拼写错误给出警告
+ */
+
+import (
+	"fmt"
+	"github.com/vrg0/go-common/util"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+//参数类型
+const (
+	TypeString   = "string"
+	TypeInt      = "int"
+	TypeUint     = "uint"
+	TypeFloat    = "float"
+	TypeBool     = "bool"
+	TypeDuration = "duration"
+	TypeList     = "list"
+)
+
+//参数声明
+type Param struct {
+	Name     string //参数名，不区分大小写
+	Desc     string //参数说明
+	Type     string //参数类型，为空时为TypeString
+	Default  string //默认值，优先级最低
+	Required bool   //是否必须存在
+}
+
+//默认声明的参数
+var builtinParams = []Param{
+	//logger和conf模块使用的参数
+	{Name: "env", Desc: "运行环境，为dev时日志打印到stdout且等级为debug", Default: "dev"},
+	{Name: "log_path", Desc: "日志路径，dev环境默认为/dev/stdout，其他环境默认为程序名+.log"},
+	{Name: "log_format", Desc: "日志格式：console | json", Default: "console"},
+	{Name: "config_serve", Desc: "apollo服务器的地址，如：localhost:8080"},
+	{Name: "app_name", Desc: "apollo中的AppId"},
+	{Name: "idc", Desc: "apollo中的Cluster"},
+	{Name: "cache_file_path", Desc: "apollo缓存文件的路径，默认为程序名+.cache_file"},
+
+	//args模块使用的参数
+	{Name: "env_file", Desc: ".env文件的路径", Default: defaultDotEnvPath},
+	{Name: "config", Desc: "配置文件的路径，支持json、yaml、toml"},
+}
+
+//声明参数，重复声明时后者覆盖前者
+func (a *Args) Register(params ...Param) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	for _, param := range params {
+		param.Name = strings.ToLower(param.Name)
+		if param.Type == "" {
+			param.Type = TypeString
+		}
+		p := param
+		a.paramMap[param.Name] = &p
+	}
+}
+
+//获取所有声明过的参数，按参数名排序
+func (a *Args) Params() []Param {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	rtn := make([]Param, 0, len(a.paramMap))
+	for _, param := range a.paramMap {
+		rtn = append(rtn, *param)
+	}
+	sort.Slice(rtn, func(i, j int) bool {
+		return rtn[i].Name < rtn[j].Name
+	})
+	return rtn
+}
+
+//输出帮助信息
+func (a *Args) Usage(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "Usage: %s [key=value ...]\n\n", filepath.Base(os.Args[0]))
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, param := range a.Params() {
+		extra := make([]string, 0, 2)
+		if param.Default != "" {
+			extra = append(extra, "默认: "+param.Default)
+		}
+		if param.Required {
+			extra = append(extra, "必填")
+		}
+		desc := param.Desc
+		if len(extra) != 0 {
+			desc += " (" + strings.Join(extra, ", ") + ")"
+		}
+		_, _ = fmt.Fprintf(tw, "  %s\t%s\t%s\n", param.Name, param.Type, desc)
+	}
+	_ = tw.Flush()
+}
+
+//[命令行参数]中是否有-h或--help
+func (a *Args) Help() bool {
+	return a.help
+}
+
+//[命令行参数]和带前缀的[环境变量]中未声明的key，按key排序
+func (a *Args) Unknown() []string {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	rtn := make([]string, 0)
+	for key := range a.cmdKeys {
+		if _, ok := a.paramMap[key]; !ok {
+			rtn = append(rtn, key)
+		}
+	}
+	sort.Strings(rtn)
+	return rtn
+}
+
+//检查声明过的参数，所有缺失的必填参数和格式错误的参数会汇总到*Error中返回
+//读取.env文件和配置文件时的错误也会汇总到*Error中
+func (a *Args) Validate() error {
+	rtn := &Error{}
+	rtn.LoadErrors = append(rtn.LoadErrors, a.loadErrs...)
+	for _, param := range a.Params() {
+		value, ok := a.Get(param.Name)
+		if !ok {
+			if param.Required {
+				rtn.Missing = append(rtn.Missing, param.Name)
+			}
+			continue
+		}
+		if err := checkType(param.Type, value); err != nil {
+			rtn.Malformed = append(rtn.Malformed, &FieldError{Key: param.Name, Value: value, Err: err})
+		}
+	}
+	if rtn.empty() {
+		return nil
+	}
+	return rtn
+}
+
+//启动时检查参数
+//有-h或--help时输出帮助信息并退出，有未声明的key时输出警告，参数缺失或格式错误时输出错误并退出
+func (a *Args) Check() {
+	if a.Help() {
+		a.Usage(os.Stdout)
+		os.Exit(0)
+	}
+
+	for _, key := range a.Unknown() {
+		_, _ = fmt.Fprintf(os.Stderr, "args: unknown arg %q\n", key)
+	}
+
+	if err := a.Validate(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		a.Usage(os.Stderr)
+		os.Exit(2)
+	}
+}
+
+//声明参数，重复声明时后者覆盖前者
+func Register(params ...Param) {
+	Default().Register(params...)
+}
+
+//获取所有声明过的参数，按参数名排序
+func Params() []Param {
+	return Default().Params()
+}
+
+//输出帮助信息
+func Usage(w io.Writer) {
+	Default().Usage(w)
+}
+
+//[命令行参数]中是否有-h或--help
+func Help() bool {
+	return Default().Help()
+}
+
+//[命令行参数]和带前缀的[环境变量]中未声明的key，按key排序
+func Unknown() []string {
+	return Default().Unknown()
+}
+
+//检查声明过的参数，所有缺失的必填参数和格式错误的参数会汇总到*Error中返回
+func Validate() error {
+	return Default().Validate()
+}
+
+//启动时检查参数
+//有-h或--help时输出帮助信息并退出，有未声明的key时输出警告，参数缺失或格式错误时输出错误并退出
+func Check() {
+	Default().Check()
+}
+
+//检查参数值是否符合参数类型
+func checkType(paramType string, value string) error {
+	var rt reflect.Type
+	switch paramType {
+	case TypeInt:
+		rt = reflect.TypeOf(int64(0))
+	case TypeUint:
+		rt = reflect.TypeOf(uint64(0))
+	case TypeFloat:
+		rt = reflect.TypeOf(float64(0))
+	case TypeBool:
+		rt = reflect.TypeOf(false)
+	case TypeDuration:
+		rt = durationType
+	case TypeList:
+		rt = reflect.TypeOf([]string{})
+	default:
+		return nil
+	}
+	return util.SetValue(reflect.New(rt).Elem(), value)
+}
+
+//根据字段类型获取参数类型
+func typeOf(rt reflect.Type) string {
+	if rt == durationType {
+		return TypeDuration
+	}
+	switch rt.Kind() {
+	case reflect.Bool:
+		return TypeBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return TypeInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return TypeUint
+	case reflect.Float32, reflect.Float64:
+		return TypeFloat
+	case reflect.Slice:
+		return TypeList
+	default:
+		return TypeString
+	}
+}
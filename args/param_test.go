@@ -0,0 +1,77 @@
+package args
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	a := New(nil, nil)
+	a.Register(Param{Name: "Param_Port", Desc: "监听端口", Type: TypeInt, Default: "8080"})
+
+	found := false
+	for _, param := range a.Params() {
+		if param.Name == "param_port" {
+			found = true
+			if param.Type != TypeInt || param.Default != "8080" {
+				t.Error("register", param)
+			}
+		}
+	}
+	if !found {
+		t.Error("param_port not registered")
+	}
+
+	buf := new(bytes.Buffer)
+	a.Usage(buf)
+	for _, name := range []string{"param_port", "log_path", "config_serve"} {
+		if !strings.Contains(buf.String(), name) {
+			t.Error("usage without", name)
+		}
+	}
+	t.Log(buf.String())
+}
+
+func TestHelp(t *testing.T) {
+	if New(nil, []string{"--help"}).Help() != true {
+		t.Error("--help")
+	}
+	if New(nil, []string{"env=dev"}).Help() != false {
+		t.Error("no help")
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	a := New([]string{"SHELL=/bin/sh"}, []string{"log_pth=/x", "log_path=/y"})
+
+	if !reflect.DeepEqual(a.Unknown(), []string{"log_pth"}) {
+		t.Error("unknown", a.Unknown())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	a := New(nil, []string{"param_timeout=10"})
+	a.Register(
+		Param{Name: "param_required", Required: true},
+		Param{Name: "param_timeout", Type: TypeDuration},
+	)
+
+	err, ok := a.Validate().(*Error)
+	if !ok {
+		t.Fatal("validate error type")
+	}
+	if !reflect.DeepEqual(err.Missing, []string{"param_required"}) {
+		t.Error("missing", err.Missing)
+	}
+	if len(err.Malformed) != 1 || err.Malformed[0].Key != "param_timeout" {
+		t.Error("malformed", err.Malformed)
+	}
+
+	a.Set("param_required", "x")
+	a.Set("param_timeout", "10s")
+	if err := a.Validate(); err != nil {
+		t.Error(err)
+	}
+}
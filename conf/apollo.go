@@ -0,0 +1,126 @@
+package conf
+
+import (
+	"github.com/shima-park/agollo"
+	"sync"
+	"time"
+)
+
+type apolloSource struct {
+	ago       agollo.Agollo
+	loaded    *sync.Map //读取过的namespace
+	once      *sync.Once
+	startOnce *sync.Once
+	stopOnce  *sync.Once
+	errChan   chan error
+	watchChan chan *Change
+	stop      chan struct{}
+}
+
+//新建apollo配置来源，opts会覆盖默认的agollo配置，如：agollo.LongPollerInterval(time.Second)
+func NewApolloSource(configServer string, appId string, cluster string, cacheFilePath string, opts ...agollo.Option) (Source, error) {
+	ago, err := agollo.New(configServer, appId, append([]agollo.Option{
+		agollo.Cluster(cluster),                   //集群名称(idc)
+		agollo.BackupFile(cacheFilePath),          //缓存文件的路径
+		agollo.FailTolerantOnBackupExists(),       //从apollo service读取配置失败时，从缓存文件中读取配置
+		agollo.AutoFetchOnCacheMiss(),             //当缓存中找不到namespace时，自动从apollo server拉取namespace
+		agollo.LongPollerInterval(time.Second*10), //从apollo server更新数据的轮训时间
+	}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := new(sync.Map)
+	for _, namespace := range ago.Options().PreloadNamespaces {
+		loaded.Store(namespace, true)
+	}
+
+	return &apolloSource{
+		ago:       ago,
+		loaded:    loaded,
+		once:      new(sync.Once),
+		startOnce: new(sync.Once),
+		stopOnce:  new(sync.Once),
+		errChan:   make(chan error),
+		watchChan: make(chan *Change),
+		stop:      make(chan struct{}),
+	}, nil
+}
+
+//agollo停止后不会关闭channel，转发的协程在Stop时退出并关闭errChan和watchChan
+func (as *apolloSource) Start() <-chan error {
+	as.startOnce.Do(func() {
+		agoErrChan := as.ago.Start()
+		go func() {
+			defer close(as.errChan)
+			for {
+				select {
+				case e := <-agoErrChan:
+					select {
+					case as.errChan <- e.Err:
+					case <-as.stop:
+						return
+					}
+				case <-as.stop:
+					return
+				}
+			}
+		}()
+	})
+	return as.errChan
+}
+
+//缓存中没有namespace时agollo会从apollo server拉取，并开始长轮询
+func (as *apolloSource) namespace(namespace string) agollo.Configurations {
+	if _, ok := as.loaded.Load(namespace); !ok {
+		as.loaded.Store(namespace, true)
+	}
+	return as.ago.GetNameSpace(namespace)
+}
+
+func (as *apolloSource) GetNamespace(namespace string) map[string]string {
+	return mapInterfaceToString(as.namespace(namespace))
+}
+
+func (as *apolloSource) Get(namespace string, key string) (string, bool) {
+	value, ok := as.namespace(namespace)[key].(string)
+	return value, ok
+}
+
+func (as *apolloSource) Loaded(namespace string) bool {
+	_, ok := as.loaded.Load(namespace)
+	return ok
+}
+
+func (as *apolloSource) Watch() <-chan *Change {
+	as.once.Do(func() {
+		agoWatchChan := as.ago.Watch()
+		go func() {
+			defer close(as.watchChan)
+			for {
+				select {
+				case w := <-agoWatchChan:
+					select {
+					case as.watchChan <- &Change{
+						Namespace: w.Namespace,
+						OldValue:  mapInterfaceToString(w.OldValue),
+						NewValue:  mapInterfaceToString(w.NewValue),
+					}:
+					case <-as.stop:
+						return
+					}
+				case <-as.stop:
+					return
+				}
+			}
+		}()
+	})
+	return as.watchChan
+}
+
+func (as *apolloSource) Stop() {
+	as.stopOnce.Do(func() {
+		close(as.stop)
+		as.ago.Stop()
+	})
+}
@@ -0,0 +1,233 @@
+package apollotest
+
+/**
+ * 用于测试的apollo服务器
+ *
+ * 在进程内启动http服务器，实现apollo的配置接口和长轮询通知接口：
+ * /configs/{appId}/{cluster}/{namespace}：获取配置
+ * /configfiles/json/{appId}/{cluster}/{namespace}：从缓存获取配置
+ * /notifications/v2：长轮询，namespace发生变化时返回新的notificationId，超时返回304
+ *
+ * 不区分appId和cluster，通过Publish发布namespace的配置
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultPollTimeout = time.Second * 5
+
+type namespace struct {
+	id         int
+	releaseKey string
+	configs    map[string]string
+}
+
+type notification struct {
+	NamespaceName  string `json:"namespaceName"`
+	NotificationID int    `json:"notificationId"`
+}
+
+type Server struct {
+	server      *httptest.Server
+	pollTimeout time.Duration
+	lock        *sync.Mutex
+	cond        *sync.Cond
+	namespaces  map[string]*namespace
+	changed     chan struct{} //发布时关闭并重新创建，用于唤醒长轮询
+	waiting     map[string]int
+}
+
+//启动服务器，长轮询没有变化时等待pollTimeout后返回304，pollTimeout为0时为5秒
+func NewServer(pollTimeout time.Duration) *Server {
+	if pollTimeout <= 0 {
+		pollTimeout = defaultPollTimeout
+	}
+
+	rtn := &Server{
+		pollTimeout: pollTimeout,
+		lock:        new(sync.Mutex),
+		namespaces:  make(map[string]*namespace),
+		changed:     make(chan struct{}),
+		waiting:     make(map[string]int),
+	}
+	rtn.cond = sync.NewCond(rtn.lock)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/configs/", rtn.handleConfigs)
+	mux.HandleFunc("/configfiles/json/", rtn.handleConfigFiles)
+	mux.HandleFunc("/notifications/v2", rtn.handleNotifications)
+	rtn.server = httptest.NewServer(mux)
+
+	return rtn
+}
+
+//服务器的地址，如：http://127.0.0.1:12345
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+//关闭服务器，关闭后请求会失败，用于测试读取缓存文件
+func (s *Server) Close() {
+	s.lock.Lock()
+	close(s.changed)
+	s.changed = make(chan struct{})
+	s.lock.Unlock()
+	s.server.CloseClientConnections()
+	s.server.Close()
+}
+
+//发布namespace的配置，会替换namespace中的全部配置
+func (s *Server) Publish(name string, configs map[string]string) {
+	newConfigs := make(map[string]string, len(configs))
+	for k, v := range configs {
+		newConfigs[k] = v
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ns, ok := s.namespaces[name]
+	if !ok {
+		ns = &namespace{}
+		s.namespaces[name] = ns
+	}
+	ns.id++
+	ns.releaseKey = fmt.Sprintf("%s-%d", name, ns.id)
+	ns.configs = newConfigs
+
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+//等待客户端使用namespace最新的notificationId发起长轮询，即客户端已经读取了最新的配置
+//超时返回false
+func (s *Server) WaitPoll(name string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	timer := time.AfterFunc(timeout, func() {
+		s.lock.Lock()
+		s.cond.Broadcast()
+		s.lock.Unlock()
+	})
+	defer timer.Stop()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for {
+		id, ok := s.waiting[name]
+		if ok && id == s.currentId(name) {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		s.cond.Wait()
+	}
+}
+
+//namespace当前的notificationId，不存在时为-1(与客户端的初始值相同)
+func (s *Server) currentId(name string) int {
+	if ns, ok := s.namespaces[name]; ok {
+		return ns.id
+	}
+	return -1
+}
+
+//解析/prefix/{appId}/{cluster}/{namespace}
+func parsePath(path string, prefix string) (appId string, cluster string, name string) {
+	parts := strings.Split(strings.TrimPrefix(path, prefix), "/")
+	if len(parts) != 3 {
+		return "", "", ""
+	}
+	return parts[0], parts[1], parts[2]
+}
+
+func (s *Server) handleConfigs(w http.ResponseWriter, r *http.Request) {
+	appId, cluster, name := parsePath(r.URL.Path, "/configs/")
+
+	s.lock.Lock()
+	ns, ok := s.namespaces[name]
+	if !ok {
+		s.lock.Unlock()
+		http.NotFound(w, r)
+		return
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"appId":          appId,
+		"cluster":        cluster,
+		"namespaceName":  name,
+		"configurations": ns.configs,
+		"releaseKey":     ns.releaseKey,
+	})
+	s.lock.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func (s *Server) handleConfigFiles(w http.ResponseWriter, r *http.Request) {
+	_, _, name := parsePath(r.URL.Path, "/configfiles/json/")
+
+	s.lock.Lock()
+	ns, ok := s.namespaces[name]
+	if !ok {
+		s.lock.Unlock()
+		http.NotFound(w, r)
+		return
+	}
+	body, _ := json.Marshal(ns.configs)
+	s.lock.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func (s *Server) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	requests := make([]notification, 0)
+	if err := json.Unmarshal([]byte(r.URL.Query().Get("notifications")), &requests); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	timeout := time.NewTimer(s.pollTimeout)
+	defer timeout.Stop()
+
+	for {
+		s.lock.Lock()
+		changes := make([]notification, 0)
+		for _, n := range requests {
+			if id := s.currentId(n.NamespaceName); id != n.NotificationID {
+				changes = append(changes, notification{NamespaceName: n.NamespaceName, NotificationID: id})
+			}
+		}
+		if len(changes) != 0 {
+			s.lock.Unlock()
+			body, _ := json.Marshal(changes)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(body)
+			return
+		}
+
+		//客户端的配置都是最新的，等待发布
+		for _, n := range requests {
+			s.waiting[n.NamespaceName] = n.NotificationID
+		}
+		s.cond.Broadcast()
+		changed := s.changed
+		s.lock.Unlock()
+
+		select {
+		case <-changed:
+		case <-timeout.C:
+			w.WriteHeader(http.StatusNotModified)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package conf
+
+/**
+ * 把namespace绑定到结构体，namespace发生变化时重新解析
+ *
+ * Load返回的是只读的快照，每次变化都会从默认值的深拷贝生成新的结构体并原子替换，调用方不要修改快照的内容
+ * 校验失败或解析失败时保留上一次成功的快照，并通过logger打印原因
+ */
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+type Binding struct {
+	conf      *Conf
+	namespace string
+	defaults  reflect.Value //Bind传入的结构体的深拷贝，每次解析前再深拷贝一份作为默认值
+	validate  func(interface{}) error
+	lock      *sync.Mutex //保证按顺序生成和替换快照
+	value     atomic.Value
+}
+
+//绑定namespace，v为结构体指针，其中的值作为默认值，字段的tag见Unmarshal
+//validate用于校验新的快照，参数与v的类型相同，返回错误时拒绝本次变化，可以为nil
+//首次解析或校验失败时返回错误
+//如：b, err := c.Bind("application", &Config{Timeout: time.Second}, nil)，cfg := b.Load().(*Config)
+func (c *Conf) Bind(namespace string, v interface{}, validate func(interface{}) error) (*Binding, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("conf bind: %T is not a struct pointer", v)
+	}
+
+	rtn := &Binding{
+		conf:      c,
+		namespace: namespace,
+		defaults:  deepCopy(rv.Elem()),
+		validate:  validate,
+		lock:      new(sync.Mutex),
+	}
+
+	//先注册处理函数再读取namespace，避免丢失两者之间的变化
+	rtn.lock.Lock()
+	defer rtn.lock.Unlock()
+	handler := c.addNamespaceHandler(namespace, func(_ map[string]string, newCfgs map[string]string) {
+		rtn.reload(newCfgs)
+	})
+
+	snapshot, err := rtn.build(c.source.GetNamespace(namespace))
+	if err != nil {
+		c.removeNamespaceHandler(handler)
+		return nil, err
+	}
+	rtn.value.Store(snapshot)
+
+	return rtn, nil
+}
+
+//获取当前的快照，类型与Bind传入的v相同
+func (b *Binding) Load() interface{} {
+	return b.value.Load()
+}
+
+//根据namespace生成新的快照
+func (b *Binding) build(cfgs map[string]string) (interface{}, error) {
+	rv := reflect.New(b.defaults.Type())
+	rv.Elem().Set(deepCopy(b.defaults))
+	if err := b.conf.unmarshalStruct(cfgs, b.namespace, "", rv.Elem()); err != nil {
+		return nil, err
+	}
+
+	snapshot := rv.Interface()
+	if b.validate != nil {
+		if err := b.validate(snapshot); err != nil {
+			return nil, fmt.Errorf("conf bind %s: validate: %v", b.namespace, err)
+		}
+	}
+	return snapshot, nil
+}
+
+func (b *Binding) reload(cfgs map[string]string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	snapshot, err := b.build(cfgs)
+	if err != nil {
+		if b.conf.logger != nil {
+			b.conf.logger.Printf("conf bind %s: reject change, keep last snapshot: %v", b.namespace, err)
+		}
+		return
+	}
+	b.value.Store(snapshot)
+}
+
+//深拷贝map、slice、指针和结构体的导出字段，快照之间不共享可以修改的内存
+func deepCopy(v reflect.Value) reflect.Value {
+	rtn := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			p := reflect.New(v.Type().Elem())
+			p.Elem().Set(deepCopy(v.Elem()))
+			rtn.Set(p)
+		}
+	case reflect.Map:
+		if !v.IsNil() {
+			rtn.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+			for _, k := range v.MapKeys() {
+				rtn.SetMapIndex(k, deepCopy(v.MapIndex(k)))
+			}
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			rtn.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				rtn.Index(i).Set(deepCopy(v.Index(i)))
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			rtn.Index(i).Set(deepCopy(v.Index(i)))
+		}
+	case reflect.Struct:
+		rtn.Set(v)
+		copyFields(rtn, v)
+	default:
+		rtn.Set(v)
+	}
+	return rtn
+}
+
+//深拷贝结构体的导出字段，未导出的匿名结构体中的导出字段也会被拷贝
+func copyFields(dst reflect.Value, src reflect.Value) {
+	for i := 0; i < src.NumField(); i++ {
+		field := src.Type().Field(i)
+		switch {
+		case field.PkgPath == "":
+			dst.Field(i).Set(deepCopy(src.Field(i)))
+		case field.Anonymous && field.Type.Kind() == reflect.Struct:
+			copyFields(dst.Field(i), src.Field(i))
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package conf
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type tBindConfig struct {
+	Port    int           `conf:"port,required"`
+	Timeout time.Duration `conf:"timeout"`
+	Brokers []string      `conf:"brokers"`
+}
+
+func tValidatePort(v interface{}) error {
+	if v.(*tBindConfig).Port <= 0 {
+		return errors.New("port must be positive")
+	}
+	return nil
+}
+
+//发送变化，返回时watch协程已经处理完上一次的变化
+func tSendChange(source *tSource, namespace string, newValue map[string]string) {
+	source.watchChan <- &Change{Namespace: namespace, OldValue: map[string]string{}, NewValue: newValue}
+}
+
+func TestBind(t *testing.T) {
+	source := newTestSource(map[string]map[string]string{
+		"application": {"port": "8080", "brokers": "a,b"},
+	})
+	buf := new(bytes.Buffer)
+	c, err := NewWithSource(source, log.New(buf, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := c.Bind("application", &tBindConfig{Timeout: time.Second}, tValidatePort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := b.Load().(*tBindConfig)
+	if first.Port != 8080 || first.Timeout != time.Second || len(first.Brokers) != 2 {
+		t.Fatal("first snapshot", first)
+	}
+
+	//正常的变化
+	tSendChange(source, "application", map[string]string{"port": "9090", "timeout": "3s"})
+	tSendChange(source, "other", map[string]string{})
+	second := b.Load().(*tBindConfig)
+	if second.Port != 9090 || second.Timeout != time.Second*3 || len(second.Brokers) != 0 {
+		t.Error("second snapshot", second)
+	}
+	if first.Port != 8080 {
+		t.Error("old snapshot modified", first)
+	}
+
+	//校验失败和解析失败，保留上一次的快照
+	for _, newValue := range []map[string]string{
+		{"port": "-1"},
+		{"port": "abc"},
+		{"timeout": "1s"},
+	} {
+		tSendChange(source, "application", newValue)
+		tSendChange(source, "other", map[string]string{})
+		if b.Load().(*tBindConfig) != second {
+			t.Error("bad change accepted", newValue, b.Load())
+		}
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 {
+		t.Error("reject log", lines)
+	} else if !strings.Contains(lines[0], "port must be positive") || !strings.Contains(lines[2], "missing") {
+		t.Error("reject log", lines)
+	}
+}
+
+func TestBindError(t *testing.T) {
+	c, err := NewWithSource(newTestSource(map[string]map[string]string{
+		"application": {"port": "0"},
+	}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Bind("application", tBindConfig{}, nil); err == nil {
+		t.Error("not a pointer")
+	}
+	if _, err := c.Bind("application", &tBindConfig{}, tValidatePort); err == nil {
+		t.Error("validate")
+	}
+	if _, err := c.Bind("none", &tBindConfig{}, nil); !errors.Is(err, ErrMissing) {
+		t.Error("required", err)
+	}
+}
+
+func TestBindSnapshotImmutable(t *testing.T) {
+	type mapBase struct {
+		E map[string]int `conf:"e"`
+	}
+	type mapConfig struct {
+		mapBase
+		M map[string]int `conf:"m"`
+		P *tBindConfig   `conf:"p"`
+	}
+	source := newTestSource(map[string]map[string]string{
+		"application": {"m": `{"a":1}`, "p": `{"Port":1}`, "e": `{"a":1}`},
+	})
+	c, err := NewWithSource(source, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defaults := &mapConfig{mapBase: mapBase{E: map[string]int{"def": 0}}, M: map[string]int{"def": 0}, P: &tBindConfig{Port: 80}}
+	b, err := c.Bind("application", defaults, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := b.Load().(*mapConfig)
+
+	tSendChange(source, "application", map[string]string{"m": `{"b":2}`, "e": `{"b":2}`})
+	tSendChange(source, "other", map[string]string{})
+	second := b.Load().(*mapConfig)
+
+	if !reflect.DeepEqual(first.M, map[string]int{"def": 0, "a": 1}) || first.P.Port != 1 {
+		t.Error("first snapshot modified", first.M, first.P)
+	}
+	if !reflect.DeepEqual(second.M, map[string]int{"def": 0, "b": 2}) || second.P.Port != 80 {
+		t.Error("second snapshot", second.M, second.P)
+	}
+	if !reflect.DeepEqual(defaults.M, map[string]int{"def": 0}) || defaults.P.Port != 80 {
+		t.Error("defaults modified", defaults.M, defaults.P)
+	}
+	//未导出的匿名结构体中的字段
+	if !reflect.DeepEqual(first.E, map[string]int{"def": 0, "a": 1}) || !reflect.DeepEqual(defaults.E, map[string]int{"def": 0}) {
+		t.Error("embedded snapshot modified", first.E, defaults.E)
+	}
+}
+
+func TestBindConcurrent(t *testing.T) {
+	source := newTestSource(map[string]map[string]string{
+		"application": {"port": "8080"},
+	})
+	c, err := NewWithSource(source, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	//变化在watch协程中处理的同时注册处理函数
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			tSendChange(source, "application", map[string]string{"port": "9090"})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if _, err := c.Bind("application", &tBindConfig{}, nil); err != nil {
+			t.Fatal(err)
+		}
+		c.Watch("application", "port", func(string, string) {})
+	}
+	<-done
+}
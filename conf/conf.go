@@ -1,74 +1,68 @@
 package conf
 
 /**
- * 从agollo配置中心中获取配置
+ * 从配置中心中获取配置，默认使用apollo，可以通过NewWithSource使用consul kv、本地目录等其他来源
  *
  * 支持kv映射功能
  * 把配置中的 "${key}" 映射成value
- * 支${key}的嵌套，如：${hello}值为world，${AAA}值为ll, 则${he${AAA}o}值为world
- * ${key}的嵌套最多支持8层
+ * 支持${key}的嵌套、默认值、引用其他namespace、环境变量和参数，见placeholder.go
  */
 
 import (
-	"github.com/shima-park/agollo"
 	"log"
-	"strings"
-	"time"
-)
-
-const (
-	kvMapReplaceDeep  = 8
-	placeHolderPrefix = "${"
-	placeHolderSuffix = "}"
+	"sync"
 )
 
 type Conf struct {
 	kvMap            map[string]string
-	ago              agollo.Agollo
+	source           Source
 	logger           *log.Logger
 	namespaceHandler []*watchNamespaceHandler
 	keyHandler       []*watchKeyHandler
+	handlerLock      *sync.RWMutex //保护namespaceHandler和keyHandler，两者为写时复制，读取时只需取出切片
+	stopOnce         *sync.Once
+	done             chan struct{} //Stop时关闭，watch和读取错误的协程退出
 }
 
+//从apollo中读取配置
 func New(configServer string, appId string, cluster string, cacheFilePath string, logger *log.Logger) (*Conf, error) {
+	source, err := NewApolloSource(configServer, appId, cluster, cacheFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithSource(source, logger)
+}
+
+//从指定的Source中读取配置，logger为nil时不打印Source产生的错误
+func NewWithSource(source Source, logger *log.Logger) (*Conf, error) {
 	rtn := Conf{
 		kvMap:            make(map[string]string),
+		source:           source,
 		logger:           logger,
 		namespaceHandler: make([]*watchNamespaceHandler, 0),
 		keyHandler:       make([]*watchKeyHandler, 0),
+		handlerLock:      new(sync.RWMutex),
+		stopOnce:         new(sync.Once),
+		done:             make(chan struct{}),
 	}
 
-	if newAgo, err := agollo.New(
-		configServer, appId,
-		agollo.Cluster(cluster),                   //集群名称(idc)
-		agollo.BackupFile(cacheFilePath),          //缓存文件的路径
-		agollo.FailTolerantOnBackupExists(),       //从apollo service读取配置失败时，从缓存文件中读取配置
-		agollo.AutoFetchOnCacheMiss(),             //当缓存中找不到namespace时，自动从apollo server拉取namespace
-		agollo.LongPollerInterval(time.Second*10), //从apollo server更新数据的轮训时间
-	); err != nil {
-		return nil, err
-	} else {
-		rtn.ago = newAgo
-	}
-
-	//开启一个协程，从apollo server更新数据
-	if logger != nil {
-		errLogChan := rtn.ago.Start()
+	//开启一个协程，读取Source产生的错误
+	if errChan := rtn.source.Start(); errChan != nil {
 		go func() {
-			defer func() {
-				if e := recover(); e != nil {
-					return
-				}
-			}()
 			for {
 				select {
-				case e := <-errLogChan:
-					rtn.logger.Print(e)
+				case e, ok := <-errChan:
+					if !ok {
+						return
+					}
+					if rtn.logger != nil {
+						rtn.logger.Print(e)
+					}
+				case <-rtn.done:
+					return
 				}
 			}
 		}()
-	} else {
-		rtn.ago.Start()
 	}
 
 	//开启一个协程，启用watch机制
@@ -77,6 +71,14 @@ func New(configServer string, appId string, cluster string, cacheFilePath string
 	return &rtn, nil
 }
 
+//停止读取配置，并停止watch
+func (c *Conf) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+		c.source.Stop()
+	})
+}
+
 //刷新kvMap
 func (c *Conf) RefreshKvMap(kvMapper map[string]string) {
 	newKvMap := make(map[string]string)
@@ -86,38 +88,9 @@ func (c *Conf) RefreshKvMap(kvMapper map[string]string) {
 	c.kvMap = newKvMap
 }
 
-func (c *Conf) internalKvMapReplace(value string, deep int) (string, bool) {
-	if deep >= kvMapReplaceDeep {
-		return "", false
-	}
-
-	startIndex := strings.Index(value, placeHolderPrefix)
-	if startIndex == -1 {
-		return value, true
-	}
-
-	endIndex := strings.Index(value, placeHolderSuffix)
-	if endIndex == -1 {
-		return value, true
-	}
-
-	innerStr := value[startIndex+len(placeHolderPrefix) : endIndex]
-	if replaceValue, ok := c.kvMap[innerStr]; ok {
-		value = strings.ReplaceAll(value, placeHolderPrefix+innerStr+placeHolderSuffix, replaceValue)
-		return c.internalKvMapReplace(value, deep+1)
-	}
-
-	return "", false
-}
-
-//kv替换
-func (c *Conf) kvMapReplace(value string) (string, bool) {
-	return c.internalKvMapReplace(value, 0)
-}
-
 //获取指定namespace中的key，失败返回false
 func (c *Conf) Get(namespace string, key string) (string, bool) {
-	value := c.ago.Get(key, agollo.WithNamespace(namespace))
+	value, _ := c.source.Get(namespace, key)
 	if value == "" {
 		return "", false
 	}
@@ -130,7 +103,7 @@ func (c *Conf) Get(namespace string, key string) (string, bool) {
 
 //获取指定namespace中的key，失败返回默认值
 func (c *Conf) GetOrDefault(namespace string, key string, defaultValue string) string {
-	value := c.ago.Get(key, agollo.WithNamespace(namespace))
+	value, _ := c.source.Get(namespace, key)
 	if value == "" {
 		return defaultValue
 	}
@@ -145,12 +118,10 @@ func (c *Conf) GetOrDefault(namespace string, key string, defaultValue string) s
 func (c *Conf) GetNamespace(namespace string) map[string]string {
 	rtn := make(map[string]string)
 
-	configs := c.ago.GetNameSpace(namespace)
+	configs := c.source.GetNamespace(namespace)
 	for k, v := range configs {
-		if str, ok := v.(string); ok {
-			if v, ok := c.kvMapReplace(str); ok {
-				rtn[k] = v
-			}
+		if v, ok := c.kvMapReplace(v); ok {
+			rtn[k] = v
 		}
 	}
 
@@ -1,76 +1,198 @@
 package conf
 
 import (
-	"github.com/vrg0/go-common/args"
+	"github.com/shima-park/agollo"
+	"github.com/vrg0/go-common/conf/apollotest"
+	"io/ioutil"
+	"log"
 	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 )
 
-var tConf *Conf
+const tNamespace = "blacklist.url"
 
-func init() {
-	configServer, ok := args.Get("config_server")
-	if !ok {
-		return
+var tConfigs = map[string]string{
+	"kkk": "vvv",
+	"kv":  "/${123}",
+}
+
+//新建连接测试服务器的Conf
+func newTestConf(t *testing.T, configServer string, cacheFilePath string) *Conf {
+	source, err := NewApolloSource(configServer, "test_app", "default", cacheFilePath,
+		agollo.LongPollerInterval(time.Millisecond*10))
+	if err != nil {
+		t.Fatal(err)
 	}
-	appName, ok := args.Get("app_name")
-	if !ok {
-		return
+	c, err := NewWithSource(source, nil)
+	if err != nil {
+		t.Fatal(err)
 	}
-	idc, ok := args.Get("idc")
-	if !ok {
-		return
+	return c
+}
+
+//启动测试服务器并发布tConfigs，返回连接测试服务器的Conf
+func startTestConf(t *testing.T) (*apollotest.Server, *Conf, func()) {
+	dir, err := ioutil.TempDir("", "conf_test")
+	if err != nil {
+		t.Fatal(err)
 	}
-	cacheFilePath := args.GetOrDefault("cache_file_path", os.Args[0]+".cache_file")
 
-	if newConf, err := New(configServer, appName, idc, cacheFilePath, nil); err != nil {
-		return
-	} else {
-		tConf = newConf
+	srv := apollotest.NewServer(time.Second)
+	srv.Publish(tNamespace, tConfigs)
+	c := newTestConf(t, srv.URL(), filepath.Join(dir, "cache_file"))
+
+	return srv, c, func() {
+		c.Stop()
+		srv.Close()
+		_ = os.RemoveAll(dir)
 	}
 }
 
 func TestGet(t *testing.T) {
-	v, ok := tConf.Get("blacklist.url", "kkk")
-	if !ok {
-		t.Error("not found conf")
-	} else {
-		t.Log(v)
+	_, tConf, stop := startTestConf(t)
+	defer stop()
+
+	v, ok := tConf.Get(tNamespace, "kkk")
+	if !ok || v != "vvv" {
+		t.Error("not found conf", v)
 	}
 }
 
 func TestGet2(t *testing.T) {
-	v, ok := tConf.Get("blacklist.url", "none")
+	_, tConf, stop := startTestConf(t)
+	defer stop()
+
+	v, ok := tConf.Get(tNamespace, "none")
+	if ok {
+		t.Error(v)
+	}
+	v, ok = tConf.Get("none.namespace", "kkk")
 	if ok {
 		t.Error(v)
-	} else {
-		t.Log(v)
 	}
 }
 
 func TestGetNamespace(t *testing.T) {
-	v := tConf.GetNamespace("blacklist.url")
-	t.Log(v)
+	_, tConf, stop := startTestConf(t)
+	defer stop()
+
+	v := tConf.GetNamespace(tNamespace)
+	if len(v) != 1 || v["kkk"] != "vvv" {
+		t.Error("namespace without unresolved kv", v)
+	}
 }
 
 func TestGetOrDefault(t *testing.T) {
-	v := tConf.GetOrDefault("blacklist.url", "none", "123")
+	_, tConf, stop := startTestConf(t)
+	defer stop()
+
+	v := tConf.GetOrDefault(tNamespace, "none", "123")
 	if v != "123" {
-		t.Log(v)
+		t.Error(v)
 	}
 
-	v2 := tConf.GetOrDefault("blacklist.url", "kkk", "345")
-	if v2 == "345" {
-		t.Log(v2)
+	v2 := tConf.GetOrDefault(tNamespace, "kkk", "345")
+	if v2 != "vvv" {
+		t.Error(v2)
 	}
 }
 
 func TestRefreshKvMap(t *testing.T) {
-	tConf.RefreshKvMap(map[string]string{"123":"aaa"})
-	v := tConf.GetOrDefault("blacklist.url", "kv", "xxx")
+	_, tConf, stop := startTestConf(t)
+	defer stop()
+
+	//kv映射不存在时获取失败
+	if v, ok := tConf.Get(tNamespace, "kv"); ok {
+		t.Error(v)
+	}
+
+	tConf.RefreshKvMap(map[string]string{"123": "aaa"})
+	v := tConf.GetOrDefault(tNamespace, "kv", "xxx")
 	if v != "/aaa" {
 		t.Error(v)
-	} else {
-		t.Log(v)
 	}
-}
\ No newline at end of file
+
+	//嵌套的kv映射
+	tConf.RefreshKvMap(map[string]string{"123": "${456}", "456": "bbb"})
+	v = tConf.GetOrDefault(tNamespace, "kv", "xxx")
+	if v != "/bbb" {
+		t.Error(v)
+	}
+}
+
+func TestBackupFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conf_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	cacheFilePath := filepath.Join(dir, "cache_file")
+
+	//从服务器读取配置，并写入缓存文件
+	srv := apollotest.NewServer(time.Second)
+	srv.Publish(tNamespace, tConfigs)
+	c := newTestConf(t, srv.URL(), cacheFilePath)
+	if v, ok := c.Get(tNamespace, "kkk"); !ok || v != "vvv" {
+		t.Error("get from server", v)
+	}
+	c.Stop()
+	srv.Close()
+
+	//服务器不可用时从缓存文件中读取配置
+	c = newTestConf(t, srv.URL(), cacheFilePath)
+	defer c.Stop()
+	if v, ok := c.Get(tNamespace, "kkk"); !ok || v != "vvv" {
+		t.Error("get from backup file", v)
+	}
+}
+
+//等待协程数量恢复到n以下
+func waitGoroutines(n int, timeout time.Duration) int {
+	deadline := time.Now().Add(timeout)
+	for {
+		current := runtime.NumGoroutine()
+		if current <= n || time.Now().After(deadline) {
+			return current
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestStop(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conf_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	base := runtime.NumGoroutine()
+
+	fileConf, err := NewWithSource(NewFileSource(dir, time.Millisecond*10), log.New(ioutil.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileConf.GetNamespace(tNamespace)
+	fileConf.Stop()
+	fileConf.Stop()
+
+	srv, apolloConf, stop := startTestConf(t)
+	apolloConf.GetNamespace(tNamespace)
+	if !srv.WaitPoll(tNamespace, time.Second*5) {
+		t.Fatal("wait poll timeout")
+	}
+	stop()
+
+	if n := waitGoroutines(base, time.Second*5); n > base {
+		t.Error("goroutine leak", base, n)
+	}
+	if v, ok := apolloConf.Get(tNamespace, "kkk"); !ok || v != "vvv" {
+		t.Error("get after stop", v)
+	}
+}
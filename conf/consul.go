@@ -0,0 +1,165 @@
+package conf
+
+import (
+	"github.com/hashicorp/consul/api"
+	"github.com/vrg0/go-common/consul-kv"
+	"strings"
+	"sync"
+)
+
+type consulSource struct {
+	kv        *consul_kv.ConsulKV
+	lock      *sync.Mutex
+	cache     map[string]map[string]string //namespace -> 配置
+	watching  map[string]bool              //已经开始监控的namespace
+	errChan   chan error
+	watchChan chan *Change
+	stops     []func() //停止监控前缀的函数
+	stop      chan struct{}
+}
+
+//新建consul kv配置来源，namespace为key的前缀，如：application/db.host为application中的db.host
+//第一次读取namespace时开始监控前缀的变化
+func NewConsulSource(kv *consul_kv.ConsulKV) Source {
+	return &consulSource{
+		kv:        kv,
+		lock:      new(sync.Mutex),
+		cache:     make(map[string]map[string]string),
+		watching:  make(map[string]bool),
+		errChan:   make(chan error, 16),
+		watchChan: make(chan *Change),
+		stops:     make([]func(), 0),
+		stop:      make(chan struct{}),
+	}
+}
+
+func (cs *consulSource) Start() <-chan error {
+	return cs.errChan
+}
+
+//输出错误，没有读取时丢弃
+func (cs *consulSource) sendErr(err error) {
+	select {
+	case cs.errChan <- err:
+	default:
+	}
+}
+
+func (cs *consulSource) stopped() bool {
+	select {
+	case <-cs.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+//获取namespace的缓存，缓存的map只会被替换，不会被修改
+//第一次读取时从consul中读取并开始监控前缀的变化，停止后不再读取
+//读取失败时也开始监控，监控成功之前返回nil，不会每次都请求consul
+func (cs *consulSource) namespace(namespace string) map[string]string {
+	cs.lock.Lock()
+	if cfgs, ok := cs.cache[namespace]; ok || cs.watching[namespace] || cs.stopped() {
+		cs.lock.Unlock()
+		return cfgs
+	}
+	cs.lock.Unlock()
+
+	prefix := namespace + "/"
+	pairs, err := cs.kv.List(prefix)
+	if err != nil {
+		cs.sendErr(err)
+	}
+
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+	if cached, ok := cs.cache[namespace]; ok || cs.stopped() {
+		return cached
+	}
+	if !cs.watching[namespace] {
+		cs.watching[namespace] = true
+		cs.stops = append(cs.stops, cs.kv.WatchPrefix(prefix, func(_ uint64, pairs api.KVPairs) {
+			cs.update(namespace, consulNamespace(prefix, pairs))
+		}))
+	}
+	if err != nil {
+		return nil
+	}
+	cfgs := make(map[string]string)
+	for _, pair := range pairs {
+		if key := strings.TrimPrefix(pair.Key, prefix); key != "" {
+			cfgs[key] = pair.Value
+		}
+	}
+	cs.cache[namespace] = cfgs
+	return cfgs
+}
+
+func (cs *consulSource) GetNamespace(namespace string) map[string]string {
+	return copyNamespace(cs.namespace(namespace))
+}
+
+func (cs *consulSource) Get(namespace string, key string) (string, bool) {
+	value, ok := cs.namespace(namespace)[key]
+	return value, ok
+}
+
+func (cs *consulSource) Loaded(namespace string) bool {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+	_, ok := cs.cache[namespace]
+	return ok
+}
+
+//把前缀下的kv对转换为namespace中的配置
+func consulNamespace(prefix string, pairs api.KVPairs) map[string]string {
+	rtn := make(map[string]string)
+	for _, pair := range pairs {
+		if key := strings.TrimPrefix(pair.Key, prefix); key != "" {
+			rtn[key] = string(pair.Value)
+		}
+	}
+	return rtn
+}
+
+//第一次读取失败时，监控成功后在这里写入缓存
+func (cs *consulSource) update(namespace string, newCfgs map[string]string) {
+	cs.lock.Lock()
+	oldCfgs, loaded := cs.cache[namespace]
+	if cs.stopped() || (loaded && equalNamespace(oldCfgs, newCfgs)) {
+		cs.lock.Unlock()
+		return
+	}
+	cs.cache[namespace] = newCfgs
+	cs.lock.Unlock()
+	if equalNamespace(oldCfgs, newCfgs) {
+		return
+	}
+
+	select {
+	case cs.watchChan <- &Change{
+		Namespace: namespace,
+		OldValue:  copyNamespace(oldCfgs),
+		NewValue:  copyNamespace(newCfgs),
+	}:
+	case <-cs.stop:
+	}
+}
+
+func (cs *consulSource) Watch() <-chan *Change {
+	return cs.watchChan
+}
+
+//停止监控全部前缀，停止后忽略配置的变化
+func (cs *consulSource) Stop() {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+	if cs.stopped() {
+		return
+	}
+	close(cs.stop)
+	for _, stop := range cs.stops {
+		stop()
+	}
+	cs.stops = nil
+}
@@ -0,0 +1,185 @@
+package conf
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultFileSourceInterval = time.Second * 5
+
+type fileSource struct {
+	dir       string
+	interval  time.Duration
+	lock      *sync.Mutex
+	cache     map[string]map[string]string //namespace -> 配置
+	once      *sync.Once
+	errChan   chan error
+	watchChan chan *Change
+	stop      chan struct{}
+}
+
+//新建本地目录配置来源，每个文件为一个namespace，文件名为namespace或namespace+".properties"
+//文件格式为properties：每行一个key=value或key: value，"#"和"!"开头的行为注释
+//每interval检查一次读取过的namespace的变化，interval为0时为5秒
+func NewFileSource(dir string, interval time.Duration) Source {
+	if interval <= 0 {
+		interval = defaultFileSourceInterval
+	}
+	return &fileSource{
+		dir:       dir,
+		interval:  interval,
+		lock:      new(sync.Mutex),
+		cache:     make(map[string]map[string]string),
+		once:      new(sync.Once),
+		errChan:   make(chan error, 16),
+		watchChan: make(chan *Change),
+		stop:      make(chan struct{}),
+	}
+}
+
+func (fs *fileSource) Start() <-chan error {
+	fs.once.Do(func() {
+		go fs.poll()
+	})
+	return fs.errChan
+}
+
+func (fs *fileSource) sendErr(err error) {
+	select {
+	case fs.errChan <- err:
+	default:
+	}
+}
+
+//读取namespace对应的文件，文件不存在时返回空map
+func (fs *fileSource) load(namespace string) map[string]string {
+	for _, path := range []string{namespace, namespace + ".properties"} {
+		cfgs, err := loadProperties(filepath.Join(fs.dir, path))
+		if err == nil {
+			return cfgs
+		}
+		if !os.IsNotExist(err) {
+			fs.sendErr(err)
+		}
+	}
+	return make(map[string]string)
+}
+
+//获取namespace的缓存，缓存的map只会被替换，不会被修改
+func (fs *fileSource) namespace(namespace string) map[string]string {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	cfgs, ok := fs.cache[namespace]
+	if !ok {
+		cfgs = fs.load(namespace)
+		fs.cache[namespace] = cfgs
+	}
+	return cfgs
+}
+
+func (fs *fileSource) GetNamespace(namespace string) map[string]string {
+	return copyNamespace(fs.namespace(namespace))
+}
+
+func (fs *fileSource) Get(namespace string, key string) (string, bool) {
+	value, ok := fs.namespace(namespace)[key]
+	return value, ok
+}
+
+func (fs *fileSource) Loaded(namespace string) bool {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	_, ok := fs.cache[namespace]
+	return ok
+}
+
+//watchChan只由poll写入，poll退出时关闭
+func (fs *fileSource) poll() {
+	defer close(fs.watchChan)
+
+	ticker := time.NewTicker(fs.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, change := range fs.reload() {
+				select {
+				case fs.watchChan <- change:
+				case <-fs.stop:
+					return
+				}
+			}
+		case <-fs.stop:
+			return
+		}
+	}
+}
+
+//重新读取读取过的namespace，返回发生变化的namespace
+func (fs *fileSource) reload() []*Change {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	rtn := make([]*Change, 0)
+	for namespace, oldCfgs := range fs.cache {
+		newCfgs := fs.load(namespace)
+		if equalNamespace(oldCfgs, newCfgs) {
+			continue
+		}
+		fs.cache[namespace] = newCfgs
+		rtn = append(rtn, &Change{
+			Namespace: namespace,
+			OldValue:  copyNamespace(oldCfgs),
+			NewValue:  copyNamespace(newCfgs),
+		})
+	}
+	return rtn
+}
+
+func (fs *fileSource) Watch() <-chan *Change {
+	return fs.watchChan
+}
+
+func (fs *fileSource) Stop() {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	select {
+	case <-fs.stop:
+	default:
+		close(fs.stop)
+	}
+}
+
+//解析properties文件
+func loadProperties(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	rtn := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		index := strings.IndexAny(line, "=:")
+		if index <= 0 {
+			continue
+		}
+		rtn[strings.TrimSpace(line[:index])] = strings.TrimSpace(line[index+1:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rtn, nil
+}
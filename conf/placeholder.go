@@ -0,0 +1,273 @@
+package conf
+
+/**
+ * 配置中的占位符
+ *
+ * ${key}：kv映射(RefreshKvMap)中的key
+ * ${namespace.key}：其他namespace中的key，如：${application.db.host}，namespace中也可以包含"."
+ *   只查找已经读取过的namespace(Get、GetNamespace、Watch等读取过，或apollo预加载的namespace)，不会因为占位符读取新的namespace
+ * ${env:KEY}：环境变量
+ * ${args:key}：args模块的参数
+ * ${key:-fallback}：key不存在时使用fallback，fallback中也可以包含占位符
+ * $${：转义为"${"，不进行替换
+ *
+ * 一个value中可以有多个占位符，占位符可以嵌套，如：${he${AAA}o}，先替换内层的${AAA}
+ * kv映射和namespace中的值会继续替换，引用最多8层，出现循环引用时返回错误
+ * key不存在且没有fallback时替换失败
+ * 没有匹配的"}"时，从"${"开始的内容原样保留
+ */
+
+import (
+	"fmt"
+	"github.com/vrg0/go-common/args"
+	"os"
+	"strings"
+)
+
+const (
+	kvMapReplaceDeep   = 8
+	placeHolderEscape  = "$${"
+	placeHolderPrefix  = "${"
+	placeHolderSuffix  = "}"
+	placeHolderDefault = ":-"
+	envPrefix          = "env:"
+	argsPrefix         = "args:"
+)
+
+//占位符的来源
+const (
+	FromKvMap     = "kv"
+	FromNamespace = "namespace"
+	FromEnv       = "env"
+	FromArgs      = "args"
+	FromDefault   = "default"
+)
+
+//一次占位符替换
+type Substitution struct {
+	Placeholder string //占位符的原文，如：${db.host:-localhost}
+	Key         string //替换内层占位符之后的key，如：db.host
+	From        string //kv | namespace | env | args | default
+	Namespace   string //From为namespace时有效
+	Value       string //替换后的值
+	Depth       int    //引用的层数，value中直接出现的占位符为0
+}
+
+//替换的结果，Substitutions按完成的顺序排列，内层的占位符在前
+type Resolution struct {
+	Raw           string
+	Value         string
+	Substitutions []Substitution
+}
+
+type resolver struct {
+	conf  *Conf
+	kvMap map[string]string
+	stack []string //正在替换的引用，用于检测循环引用
+	subs  []Substitution
+}
+
+//替换value中的占位符
+func (c *Conf) expand(value string) (*Resolution, error) {
+	r := &resolver{
+		conf:  c,
+		kvMap: c.kvMap,
+		stack: make([]string, 0),
+		subs:  make([]Substitution, 0),
+	}
+	rtn, err := r.expand(value)
+	if err != nil {
+		return nil, err
+	}
+	return &Resolution{Raw: value, Value: rtn, Substitutions: r.subs}, nil
+}
+
+//kv替换
+func (c *Conf) kvMapReplace(value string) (string, bool) {
+	rtn, err := c.expand(value)
+	if err != nil {
+		return "", false
+	}
+	return rtn.Value, true
+}
+
+//获取key并说明占位符的替换过程，失败时返回*Error
+func (c *Conf) Resolve(namespace string, key string) (*Resolution, error) {
+	value, ok := c.source.Get(namespace, key)
+	if !ok {
+		return nil, &Error{Namespace: namespace, Key: key, Err: ErrMissing}
+	}
+	rtn, err := c.expand(value)
+	if err != nil {
+		return nil, invalid(namespace, key, value, err)
+	}
+	return rtn, nil
+}
+
+//查找与value[start:]处的"${"匹配的"}"，不存在时返回-1
+func matchSuffix(value string, start int) int {
+	level := 0
+	for i := start; i < len(value); {
+		switch {
+		case strings.HasPrefix(value[i:], placeHolderEscape):
+			i += len(placeHolderEscape)
+		case strings.HasPrefix(value[i:], placeHolderPrefix):
+			level++
+			i += len(placeHolderPrefix)
+		case strings.HasPrefix(value[i:], placeHolderSuffix):
+			level--
+			if level == 0 {
+				return i
+			}
+			i += len(placeHolderSuffix)
+		default:
+			i++
+		}
+	}
+	return -1
+}
+
+//查找不在内层占位符中的":-"，不存在时返回-1
+func indexDefault(expr string) int {
+	level := 0
+	for i := 0; i < len(expr); {
+		switch {
+		case strings.HasPrefix(expr[i:], placeHolderEscape):
+			i += len(placeHolderEscape)
+		case strings.HasPrefix(expr[i:], placeHolderPrefix):
+			level++
+			i += len(placeHolderPrefix)
+		case strings.HasPrefix(expr[i:], placeHolderSuffix):
+			level--
+			i += len(placeHolderSuffix)
+		case level == 0 && strings.HasPrefix(expr[i:], placeHolderDefault):
+			return i
+		default:
+			i++
+		}
+	}
+	return -1
+}
+
+func (r *resolver) expand(value string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(value); {
+		switch {
+		case strings.HasPrefix(value[i:], placeHolderEscape):
+			sb.WriteString(placeHolderPrefix)
+			i += len(placeHolderEscape)
+		case strings.HasPrefix(value[i:], placeHolderPrefix):
+			end := matchSuffix(value, i)
+			if end == -1 {
+				sb.WriteString(value[i:])
+				return sb.String(), nil
+			}
+			rtn, err := r.replace(value[i : end+len(placeHolderSuffix)])
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(rtn)
+			i = end + len(placeHolderSuffix)
+		default:
+			sb.WriteByte(value[i])
+			i++
+		}
+	}
+	return sb.String(), nil
+}
+
+//替换一个占位符，placeholder包括"${"和"}"
+func (r *resolver) replace(placeholder string) (string, error) {
+	expr := placeholder[len(placeHolderPrefix) : len(placeholder)-len(placeHolderSuffix)]
+	name, fallback, hasFallback := expr, "", false
+	if index := indexDefault(expr); index != -1 {
+		name, fallback, hasFallback = expr[:index], expr[index+len(placeHolderDefault):], true
+	}
+
+	key, err := r.expand(name)
+	if err != nil {
+		return "", err
+	}
+
+	sub := Substitution{Placeholder: placeholder, Key: key, Depth: len(r.stack)}
+	value, found, err := r.lookup(key, &sub)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		if !hasFallback {
+			return "", fmt.Errorf("unknown key %q in %s", key, placeholder)
+		}
+		if value, err = r.expand(fallback); err != nil {
+			return "", err
+		}
+		sub.From = FromDefault
+	}
+
+	sub.Value = value
+	r.subs = append(r.subs, sub)
+	return value, nil
+}
+
+//查找key的值，kv映射和namespace中的值会继续替换
+func (r *resolver) lookup(key string, sub *Substitution) (string, bool, error) {
+	if strings.HasPrefix(key, envPrefix) {
+		sub.From = FromEnv
+		value, ok := os.LookupEnv(strings.TrimPrefix(key, envPrefix))
+		return value, ok, nil
+	}
+	if strings.HasPrefix(key, argsPrefix) {
+		sub.From = FromArgs
+		value, ok := args.Get(strings.TrimPrefix(key, argsPrefix))
+		return value, ok, nil
+	}
+
+	if value, ok := r.kvMap[key]; ok {
+		sub.From = FromKvMap
+		return r.reference(key, value)
+	}
+
+	//依次尝试在每个"."处分割namespace和key，只查找已经读取过的namespace
+	source := r.conf.source
+	for i := strings.Index(key, "."); i != -1; {
+		namespace := key[:i]
+		if source.Loaded(namespace) {
+			if value, ok := source.Get(namespace, key[i+1:]); ok {
+				sub.From = FromNamespace
+				sub.Namespace = namespace
+				return r.reference(key, value)
+			}
+		}
+		next := strings.Index(key[i+1:], ".")
+		if next == -1 {
+			break
+		}
+		i += next + 1
+	}
+
+	return "", false, nil
+}
+
+//替换被引用的值，检测循环引用
+func (r *resolver) reference(key string, value string) (string, bool, error) {
+	for index, k := range r.stack {
+		if k == key {
+			path := append(append([]string{}, r.stack[index:]...), key)
+			return "", false, fmt.Errorf("placeholder cycle: %s", strings.Join(path, " -> "))
+		}
+	}
+	if len(r.stack) >= kvMapReplaceDeep {
+		return "", false, fmt.Errorf("placeholder reference deeper than %d: %s -> %s", kvMapReplaceDeep, strings.Join(r.stack, " -> "), key)
+	}
+
+	r.stack = append(r.stack, key)
+	defer func() {
+		r.stack = r.stack[:len(r.stack)-1]
+	}()
+
+	rtn, err := r.expand(value)
+	if err != nil {
+		return "", false, err
+	}
+	return rtn, true, nil
+}
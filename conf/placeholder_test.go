@@ -0,0 +1,167 @@
+package conf
+
+import (
+	"errors"
+	"github.com/vrg0/go-common/args"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newPlaceholderConf(t *testing.T) *Conf {
+	c, err := NewWithSource(newTestSource(map[string]map[string]string{
+		"application": {
+			"host": "127.0.0.1",
+			"url":  "http://${application.host}:${port:-8080}/${path}",
+			"a":    "${application.b}",
+			"b":    "${application.a}",
+		},
+		"blacklist.url": {
+			"db.host": "10.0.0.1",
+		},
+	}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.RefreshKvMap(map[string]string{
+		"hello": "world",
+		"AAA":   "ll",
+		"path":  "${hello}",
+		"self":  "${self}",
+	})
+	return c
+}
+
+func TestPlaceholder(t *testing.T) {
+	c := newPlaceholderConf(t)
+	_ = os.Setenv("CONF_PLACEHOLDER_TEST", "from_env")
+	defer os.Unsetenv("CONF_PLACEHOLDER_TEST")
+	restore := args.Override("placeholder_test", "from_args")
+	defer restore()
+
+	testCase := map[string]string{
+		"${hello}-${AAA}":                      "world-ll",
+		"${he${AAA}o}":                         "world",
+		"${AAA}${he${AAA}o}${AAA}":             "llworldll",
+		"${none:-fallback}":                    "fallback",
+		"${none:-${hello}}":                    "world",
+		"${none:-}":                            "",
+		"${hello:-fallback}":                   "world",
+		"${blacklist.url.db.host}":             "10.0.0.1",
+		"${env:CONF_PLACEHOLDER_TEST}":         "from_env",
+		"${args:placeholder_test}":             "from_args",
+		"$${hello}":                            "${hello}",
+		"$${${hello}}":                         "${world}",
+		"$100 {}":                              "$100 {}",
+		"${application.url}":                   "http://127.0.0.1:8080/world",
+		"${none:-a:-b}":                        "a:-b",
+		"${env:CONF_PLACEHOLDER_NONE:-${AAA}}": "ll",
+		"${hello":                              "${hello",
+		"${AAA}-${none:-${hello}":              "ll-${none:-${hello}",
+		"${db.host:-localhost}":                "localhost",
+	}
+	for value, expect := range testCase {
+		rtn, err := c.expand(value)
+		if err != nil {
+			t.Error(value, err)
+		} else if rtn.Value != expect {
+			t.Error(value, rtn.Value)
+		}
+	}
+}
+
+func TestPlaceholderError(t *testing.T) {
+	c := newPlaceholderConf(t)
+
+	testCase := map[string]string{
+		"${none}":            `unknown key "none"`,
+		"${self}":            "placeholder cycle: self -> self",
+		"${application.a}":   "placeholder cycle: application.a -> application.b -> application.a",
+		"${he${none}o}":      `unknown key "none"`,
+		"${env:CONF_NONE_X}": `unknown key "env:CONF_NONE_X"`,
+	}
+	for value, expect := range testCase {
+		if _, err := c.expand(value); err == nil || !strings.Contains(err.Error(), expect) {
+			t.Error(value, err)
+		}
+	}
+
+	if _, ok := c.kvMapReplace("${self}"); ok {
+		t.Error("kvMapReplace cycle")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	c := newPlaceholderConf(t)
+
+	rtn, err := c.Resolve("application", "url")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rtn.Raw != "http://${application.host}:${port:-8080}/${path}" || rtn.Value != "http://127.0.0.1:8080/world" {
+		t.Error(rtn)
+	}
+
+	expect := []Substitution{
+		{Placeholder: "${application.host}", Key: "application.host", From: FromNamespace, Namespace: "application", Value: "127.0.0.1"},
+		{Placeholder: "${port:-8080}", Key: "port", From: FromDefault, Value: "8080"},
+		{Placeholder: "${hello}", Key: "hello", From: FromKvMap, Value: "world", Depth: 1},
+		{Placeholder: "${path}", Key: "path", From: FromKvMap, Value: "world"},
+	}
+	if len(rtn.Substitutions) != len(expect) {
+		t.Fatal(rtn.Substitutions)
+	}
+	for i := range expect {
+		if rtn.Substitutions[i] != expect[i] {
+			t.Error(i, rtn.Substitutions[i])
+		}
+	}
+
+	if _, err := c.Resolve("application", "none"); !errors.Is(err, ErrMissing) {
+		t.Error(err)
+	}
+	if _, err := c.Resolve("application", "a"); !errors.Is(err, ErrInvalid) || !strings.Contains(err.Error(), "cycle") {
+		t.Error(err)
+	}
+}
+
+//记录Get的namespace
+type tProbeSource struct {
+	*tSource
+	gets []string
+}
+
+func (ps *tProbeSource) Get(namespace string, key string) (string, bool) {
+	ps.gets = append(ps.gets, namespace)
+	return ps.tSource.Get(namespace, key)
+}
+
+func (ps *tProbeSource) GetNamespace(namespace string) map[string]string {
+	ps.gets = append(ps.gets, namespace)
+	return ps.tSource.GetNamespace(namespace)
+}
+
+func TestPlaceholderUnloadedNamespace(t *testing.T) {
+	source := &tProbeSource{tSource: newTestSource(map[string]map[string]string{
+		"blacklist.url": {"db.host": "10.0.0.1"},
+	})}
+	c, err := NewWithSource(source, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCase := map[string]string{
+		"${db.host:-localhost}":    "localhost",
+		"${blacklist.url.db.host}": "10.0.0.1",
+		"${a.b.c:-x}":              "x",
+	}
+	for value, expect := range testCase {
+		if rtn, err := c.expand(value); err != nil || rtn.Value != expect {
+			t.Error(value, rtn, err)
+		}
+	}
+	if !reflect.DeepEqual(source.gets, []string{"blacklist.url"}) {
+		t.Error("probe unloaded namespace", source.gets)
+	}
+}
@@ -0,0 +1,53 @@
+package conf
+
+/**
+ * 配置的来源
+ *
+ * Conf通过Source读取配置，New使用apollo，NewWithSource可以指定其他来源：
+ * NewApolloSource：apollo配置中心
+ * NewConsulSource：consul kv，namespace为key的前缀，如：namespace为"application"时读取"application/"下的key
+ * NewFileSource：本地目录，每个文件为一个namespace
+ */
+
+//namespace的变化
+type Change struct {
+	Namespace string
+	OldValue  map[string]string
+	NewValue  map[string]string
+}
+
+type Source interface {
+	//开始读取配置，返回运行中产生的错误，不会产生错误时可以返回nil
+	Start() <-chan error
+	//获取namespace中的全部配置，namespace不存在时返回空map
+	GetNamespace(namespace string) map[string]string
+	//获取namespace中的key，不复制整个namespace
+	Get(namespace string, key string) (string, bool)
+	//namespace是否已经读取过，不会读取新的namespace
+	Loaded(namespace string) bool
+	//namespace变化时输出Change，多次调用返回同一个channel
+	Watch() <-chan *Change
+	//停止读取配置，停止后Start和Watch返回的channel不再输出
+	Stop()
+}
+
+//比较两个namespace是否相同
+func equalNamespace(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func copyNamespace(namespace map[string]string) map[string]string {
+	rtn := make(map[string]string, len(namespace))
+	for k, v := range namespace {
+		rtn[k] = v
+	}
+	return rtn
+}
@@ -0,0 +1,106 @@
+package conf
+
+import (
+	"github.com/hashicorp/consul/api"
+	"github.com/vrg0/go-common/consul-kv"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conf_file_source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	path := filepath.Join(dir, "application.properties")
+	if err := ioutil.WriteFile(path, []byte("# comment\nurl = /${123}\ntimeout: 3s\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewWithSource(NewFileSource(dir, time.Millisecond*10), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	c.RefreshKvMap(map[string]string{"123": "aaa"})
+
+	if v, ok := c.Get("application", "url"); !ok || v != "/aaa" {
+		t.Error("get", v)
+	}
+	if v := c.GetOrDefault("application", "none", "default"); v != "default" {
+		t.Error("get or default", v)
+	}
+	if v := c.GetNamespace("none"); len(v) != 0 {
+		t.Error("get none namespace", v)
+	}
+
+	changed := make(chan [2]string, 1)
+	c.Watch("application", "timeout", func(oldCfg string, newCfg string) {
+		changed <- [2]string{oldCfg, newCfg}
+	})
+	if v := <-changed; v != [2]string{"", "3s"} {
+		t.Error("watch first load", v)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("url=/${123}\ntimeout=5s\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case v := <-changed:
+		if v != [2]string{"3s", "5s"} {
+			t.Error("watch change", v)
+		}
+	case <-time.After(time.Second):
+		t.Error("watch timeout")
+	}
+}
+
+func TestConsulNamespace(t *testing.T) {
+	pairs := api.KVPairs{
+		{Key: "application/", Value: nil},
+		{Key: "application/db.host", Value: []byte("127.0.0.1")},
+		{Key: "application/db/port", Value: []byte("3306")},
+	}
+	expect := map[string]string{"db.host": "127.0.0.1", "db/port": "3306"}
+	if v := consulNamespace("application/", pairs); !reflect.DeepEqual(v, expect) {
+		t.Error("consul namespace", v)
+	}
+}
+
+func TestConsulSourceUnavailable(t *testing.T) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	source := NewConsulSource(consul_kv.New("dc1", []string{strings.TrimPrefix(server.URL, "http://")}))
+	defer source.Stop()
+
+	//读取失败后由监控协程重试，不会每次读取都请求consul
+	for i := 0; i < 10; i++ {
+		if _, ok := source.Get("application", "port"); ok {
+			t.Fatal("get from unavailable consul")
+		}
+	}
+	if source.Loaded("application") {
+		t.Error("loaded")
+	}
+	time.Sleep(time.Millisecond * 500)
+	if n := atomic.LoadInt64(&requests); n > 2 {
+		t.Error("requests", n)
+	}
+}
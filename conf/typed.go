@@ -0,0 +1,236 @@
+package conf
+
+/**
+ * 获取指定类型的配置
+ *
+ * 获取失败时返回*Error，可以通过errors.Is区分失败的原因：
+ * ErrMissing：namespace中没有这个key
+ * ErrEmpty：value为空字符串
+ * ErrInvalid：value无法解析为指定的类型，或kv映射失败
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/vrg0/go-common/util"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMissing = errors.New("missing")
+	ErrEmpty   = errors.New("empty")
+	ErrInvalid = errors.New("invalid")
+)
+
+type Error struct {
+	Namespace string
+	Key       string
+	Value     string
+	Err       error //ErrMissing | ErrEmpty | ErrInvalid
+	Cause     error //解析失败的原因，Err为ErrInvalid时有效
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("conf %s %s: %s", e.Namespace, e.Key, e.Err.Error())
+	if e.Err == ErrInvalid {
+		msg += fmt.Sprintf(" value %q", e.Value)
+		if e.Cause != nil {
+			msg += ": " + e.Cause.Error()
+		}
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+//从namespace中获取key并进行kv替换
+func (c *Conf) lookup(cfgs map[string]string, namespace string, key string) (string, error) {
+	value, ok := cfgs[key]
+	return c.replaceValue(namespace, key, value, ok)
+}
+
+//对查找的结果value、ok进行kv替换
+func (c *Conf) replaceValue(namespace string, key string, value string, ok bool) (string, error) {
+	if !ok {
+		return "", &Error{Namespace: namespace, Key: key, Err: ErrMissing}
+	}
+	if value == "" {
+		return "", &Error{Namespace: namespace, Key: key, Err: ErrEmpty}
+	}
+	rtn, err := c.expand(value)
+	if err != nil {
+		return "", invalid(namespace, key, value, err)
+	}
+	return rtn.Value, nil
+}
+
+func invalid(namespace string, key string, value string, cause error) error {
+	return &Error{Namespace: namespace, Key: key, Value: value, Err: ErrInvalid, Cause: cause}
+}
+
+//获取字符串，与Get不同的是会返回失败的原因
+func (c *Conf) GetString(namespace string, key string) (string, error) {
+	value, ok := c.source.Get(namespace, key)
+	return c.replaceValue(namespace, key, value, ok)
+}
+
+func (c *Conf) GetInt(namespace string, key string) (int, error) {
+	value, err := c.GetString(namespace, key)
+	if err != nil {
+		return 0, err
+	}
+	rtn, err := util.ParseInt(strings.TrimSpace(value), 0)
+	if err != nil {
+		return 0, invalid(namespace, key, value, err)
+	}
+	return int(rtn), nil
+}
+
+func (c *Conf) GetBool(namespace string, key string) (bool, error) {
+	value, err := c.GetString(namespace, key)
+	if err != nil {
+		return false, err
+	}
+	rtn, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return false, invalid(namespace, key, value, err)
+	}
+	return rtn, nil
+}
+
+//获取时间间隔，如：300ms、1.5h
+func (c *Conf) GetDuration(namespace string, key string) (time.Duration, error) {
+	value, err := c.GetString(namespace, key)
+	if err != nil {
+		return 0, err
+	}
+	rtn, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return 0, invalid(namespace, key, value, err)
+	}
+	return rtn, nil
+}
+
+//获取字符串列表，value为json数组或逗号分隔的字符串，如：["a","b"] | a,b
+func (c *Conf) GetStringSlice(namespace string, key string) ([]string, error) {
+	value, err := c.GetString(namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	rtn, err := parseStringSlice(value)
+	if err != nil {
+		return nil, invalid(namespace, key, value, err)
+	}
+	return rtn, nil
+}
+
+//把json格式的value解析到v中
+func (c *Conf) GetJSON(namespace string, key string, v interface{}) error {
+	value, err := c.GetString(namespace, key)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return invalid(namespace, key, value, err)
+	}
+	return nil
+}
+
+func parseStringSlice(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "[") {
+		rtn := make([]string, 0)
+		if err := json.Unmarshal([]byte(value), &rtn); err != nil {
+			return nil, err
+		}
+		return rtn, nil
+	}
+	return util.SplitList(value), nil
+}
+
+//把namespace解析到结构体中，v为结构体指针
+//字段的tag为conf:"key"，如：conf:"db.host"，tag为空时使用字段名的蛇形命名，"-"表示忽略，规则和args.Bind相同
+//tag中加上",required"时key必须存在，否则返回ErrMissing，如：conf:"timeout,required"
+//嵌套的结构体使用"."连接key，如：DB struct{Host string} `conf:"db"`对应db.host，没有tag的匿名结构体展开到上一层
+//key不存在时保留字段原来的值；字符串字段的value可以为空，其他类型为空时返回ErrEmpty
+//支持string、int、uint、float、bool、time.Duration、[]string、encoding.TextUnmarshaler，其他类型按json解析
+func (c *Conf) Unmarshal(namespace string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("conf unmarshal: %T is not a struct pointer", v)
+	}
+	return c.unmarshalStruct(c.source.GetNamespace(namespace), namespace, "", rv.Elem())
+}
+
+func (c *Conf) unmarshalStruct(cfgs map[string]string, namespace string, prefix string, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		opts := strings.Split(field.Tag.Get("conf"), ",")
+		name, inline, ok := util.FieldKey(field, opts[0])
+		if !ok {
+			continue
+		}
+		required := false
+		for _, opt := range opts[1:] {
+			if strings.TrimSpace(opt) == "required" {
+				required = true
+			}
+		}
+		key := prefix + name
+		fv := rv.Field(i)
+
+		//嵌套的结构体，匿名结构体不增加前缀
+		if field.Type.Kind() == reflect.Struct && !util.IsTextUnmarshaler(field.Type) {
+			nestedPrefix := key + "."
+			if inline {
+				nestedPrefix = prefix
+			}
+			if err := c.unmarshalStruct(cfgs, namespace, nestedPrefix, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, err := c.lookup(cfgs, namespace, key)
+		switch {
+		case errors.Is(err, ErrMissing):
+			if required {
+				return err
+			}
+			continue
+		case errors.Is(err, ErrEmpty) && fv.Kind() == reflect.String:
+			fv.SetString("")
+			continue
+		case err != nil:
+			return err
+		}
+		if err := setValue(fv, value); err != nil {
+			return invalid(namespace, key, value, err)
+		}
+	}
+	return nil
+}
+
+//json数组格式的[]string和SetValue不支持的类型按json解析
+func setValue(rv reflect.Value, value string) error {
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.String {
+		list, err := parseStringSlice(value)
+		if err != nil {
+			return err
+		}
+		util.SetStringSlice(rv, list)
+		return nil
+	}
+	err := util.SetValue(rv, value)
+	if errors.Is(err, util.ErrUnsupportedType) {
+		return json.Unmarshal([]byte(value), rv.Addr().Interface())
+	}
+	return err
+}
@@ -0,0 +1,228 @@
+package conf
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+//内存中的Source
+type tSource struct {
+	namespaces map[string]map[string]string
+	watchChan  chan *Change
+}
+
+func newTestSource(namespaces map[string]map[string]string) *tSource {
+	return &tSource{namespaces: namespaces, watchChan: make(chan *Change)}
+}
+
+func (ts *tSource) Start() <-chan error {
+	return nil
+}
+
+func (ts *tSource) GetNamespace(namespace string) map[string]string {
+	return copyNamespace(ts.namespaces[namespace])
+}
+
+func (ts *tSource) Get(namespace string, key string) (string, bool) {
+	value, ok := ts.namespaces[namespace][key]
+	return value, ok
+}
+
+func (ts *tSource) Loaded(namespace string) bool {
+	_, ok := ts.namespaces[namespace]
+	return ok
+}
+
+func (ts *tSource) Watch() <-chan *Change {
+	return ts.watchChan
+}
+
+func (ts *tSource) Stop() {}
+
+func newTypedConf(t *testing.T) *Conf {
+	c, err := NewWithSource(newTestSource(map[string]map[string]string{
+		"application": {
+			"port":     "8080",
+			"debug":    "true",
+			"timeout":  "1.5s",
+			"brokers":  "a:9092, b:9092",
+			"tags":     `["x","y"]`,
+			"json":     `{"name":"abc","ids":[1,2]}`,
+			"empty":    "",
+			"bad":      "abc",
+			"unmapped": "${none}",
+			"db.host":  "127.0.0.1",
+			"db.port":  "3306",
+			"ip":       "10.0.0.1",
+			"decimal":  "010",
+			"hex":      "0x10",
+		},
+	}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestTypedGetters(t *testing.T) {
+	c := newTypedConf(t)
+
+	if v, err := c.GetInt("application", "port"); err != nil || v != 8080 {
+		t.Error("int", v, err)
+	}
+	if v, err := c.GetInt("application", "decimal"); err != nil || v != 10 {
+		t.Error("decimal int", v, err)
+	}
+	if v, err := c.GetInt("application", "hex"); err != nil || v != 16 {
+		t.Error("hex int", v, err)
+	}
+	if v, err := c.GetBool("application", "debug"); err != nil || !v {
+		t.Error("bool", v, err)
+	}
+	if v, err := c.GetDuration("application", "timeout"); err != nil || v != time.Millisecond*1500 {
+		t.Error("duration", v, err)
+	}
+	if v, err := c.GetStringSlice("application", "brokers"); err != nil || !reflect.DeepEqual(v, []string{"a:9092", "b:9092"}) {
+		t.Error("comma slice", v, err)
+	}
+	if v, err := c.GetStringSlice("application", "tags"); err != nil || !reflect.DeepEqual(v, []string{"x", "y"}) {
+		t.Error("json slice", v, err)
+	}
+	v := struct {
+		Name string `json:"name"`
+		Ids  []int  `json:"ids"`
+	}{}
+	if err := c.GetJSON("application", "json", &v); err != nil || v.Name != "abc" || len(v.Ids) != 2 {
+		t.Error("json", v, err)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	c := newTypedConf(t)
+
+	testCase := map[string]error{
+		"none":     ErrMissing,
+		"empty":    ErrEmpty,
+		"bad":      ErrInvalid,
+		"unmapped": ErrInvalid,
+	}
+	for key, expect := range testCase {
+		_, err := c.GetInt("application", key)
+		if !errors.Is(err, expect) {
+			t.Error(key, err)
+		}
+		if e, ok := err.(*Error); !ok || e.Key != key || e.Namespace != "application" {
+			t.Error(key, "error type", err)
+		}
+	}
+
+	if _, err := c.GetString("application", "empty"); !errors.Is(err, ErrEmpty) {
+		t.Error("empty string", err)
+	}
+	if err := c.GetJSON("application", "bad", &struct{}{}); !errors.Is(err, ErrInvalid) {
+		t.Error("bad json", err)
+	}
+}
+
+type tRegion string
+
+func TestUnmarshal(t *testing.T) {
+	c := newTypedConf(t)
+
+	v := struct {
+		Port    int           `conf:"port,required,omitempty"`
+		Decimal int8          `conf:"decimal"`
+		Hex     uint          `conf:"hex"`
+		Debug   bool          `conf:"debug"`
+		Timeout time.Duration `conf:"timeout"`
+		Brokers []string      `conf:"brokers"`
+		Empty   string        `conf:"empty"`
+		Missing string        `conf:"missing"`
+		IP      net.IP        `conf:"ip"`
+		Regions []tRegion     `conf:"brokers"`
+		Tags    []tRegion     `conf:"tags"`
+		Ignore  string        `conf:"-"`
+		DB      struct {
+			Host string `conf:"host"`
+			Port uint16 `conf:"port"`
+		} `conf:"db"`
+	}{Missing: "default", Empty: "default"}
+
+	if err := c.Unmarshal("application", &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Port != 8080 || !v.Debug || v.Timeout != time.Millisecond*1500 || len(v.Brokers) != 2 {
+		t.Error("unmarshal", v)
+	}
+	if !reflect.DeepEqual(v.Regions, []tRegion{"a:9092", "b:9092"}) || !reflect.DeepEqual(v.Tags, []tRegion{"x", "y"}) {
+		t.Error("unmarshal named slice", v.Regions, v.Tags)
+	}
+	if v.Decimal != 10 || v.Hex != 16 {
+		t.Error("unmarshal int", v.Decimal, v.Hex)
+	}
+	if v.Empty != "" || v.Missing != "default" || v.IP.String() != "10.0.0.1" {
+		t.Error("unmarshal", v)
+	}
+	if v.DB.Host != "127.0.0.1" || v.DB.Port != 3306 {
+		t.Error("unmarshal nested", v.DB)
+	}
+
+	required := struct {
+		None string `conf:"none,omitempty, required"`
+	}{}
+	if err := c.Unmarshal("application", &required); !errors.Is(err, ErrMissing) {
+		t.Error("required", err)
+	}
+	empty := struct {
+		Empty int `conf:"empty"`
+	}{}
+	if err := c.Unmarshal("application", &empty); !errors.Is(err, ErrEmpty) {
+		t.Error("empty int", err)
+	}
+	bad := struct {
+		Bad int `conf:"bad"`
+	}{}
+	if err := c.Unmarshal("application", &bad); !errors.Is(err, ErrInvalid) {
+		t.Error("bad int", err)
+	}
+	if err := c.Unmarshal("application", bad); err == nil {
+		t.Error("not a pointer")
+	}
+}
+
+type tUnmarshalBase struct {
+	LogPath    string
+	RetryTimes int
+}
+
+//没有tag的字段使用蛇形命名，匿名结构体展开到上一层，和args.Bind相同
+func TestUnmarshalFieldKey(t *testing.T) {
+	c, err := NewWithSource(newTestSource(map[string]map[string]string{
+		"application": {
+			"log_path":     "/tmp/x.log",
+			"retry_times":  "3",
+			"read_timeout": "1s",
+			"db.host_name": "db.local",
+		},
+	}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := struct {
+		tUnmarshalBase
+		ReadTimeout time.Duration
+		DB          struct {
+			HostName string
+		}
+	}{}
+	if err := c.Unmarshal("application", &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.LogPath != "/tmp/x.log" || v.RetryTimes != 3 || v.ReadTimeout != time.Second || v.DB.HostName != "db.local" {
+		t.Errorf("unmarshal %+v", v)
+	}
+}
@@ -26,7 +26,7 @@ func mapInterfaceToString(interfaceMap map[string]interface{}) map[string]string
 }
 
 func (c *Conf) startWatch() {
-	watchChan := c.ago.Watch()
+	watchChan := c.source.Watch()
 	go func() {
 		defer func() {
 			if e := recover(); e != nil {
@@ -37,11 +37,17 @@ func (c *Conf) startWatch() {
 
 		for {
 			select {
-			case w := <-watchChan:
-				oldValue := mapInterfaceToString(w.OldValue)
-				newValue := mapInterfaceToString(w.NewValue)
+			case <-c.done:
+				return
+			case w, ok := <-watchChan:
+				if !ok {
+					return
+				}
+				oldValue := w.OldValue
+				newValue := w.NewValue
+				namespaceHandler, keyHandler := c.handlers()
 
-				for _, v := range c.namespaceHandler {
+				for _, v := range namespaceHandler {
 					if v.Namespace == w.Namespace {
 						oldV := make(map[string]string)
 						for k, v := range oldValue {
@@ -55,7 +61,7 @@ func (c *Conf) startWatch() {
 					}
 				}
 
-				for _, v := range c.keyHandler {
+				for _, v := range keyHandler {
 					if v.Namespace == w.Namespace && oldValue[v.Key] != newValue[v.Key] {
 						v.Handler(oldValue[v.Key], newValue[v.Key])
 					}
@@ -66,23 +72,52 @@ func (c *Conf) startWatch() {
 }
 
 func (c *Conf) WatchNamespace(namespace string, handler func(oldCfgs map[string]string, newCfgs map[string]string)) {
-	//添加处理函数
+	c.addNamespaceHandler(namespace, handler)
+
+	//首次加载数据
+	handler(make(map[string]string), c.GetNamespace(namespace))
+}
+
+//取出当前的处理函数，不持有锁调用，处理函数中可以继续添加或删除处理函数
+func (c *Conf) handlers() ([]*watchNamespaceHandler, []*watchKeyHandler) {
+	c.handlerLock.RLock()
+	defer c.handlerLock.RUnlock()
+	return c.namespaceHandler, c.keyHandler
+}
+
+//添加处理函数
+func (c *Conf) addNamespaceHandler(namespace string, handler func(map[string]string, map[string]string)) *watchNamespaceHandler {
+	rtn := &watchNamespaceHandler{
+		Namespace: namespace,
+		Handler:   handler,
+	}
+	c.handlerLock.Lock()
+	defer c.handlerLock.Unlock()
 	newNamespaceHandler := make([]*watchNamespaceHandler, 0)
 	for _, watchHandler := range c.namespaceHandler {
 		newNamespaceHandler = append(newNamespaceHandler, watchHandler)
 	}
-	newNamespaceHandler = append(newNamespaceHandler, &watchNamespaceHandler{
-		Namespace: namespace,
-		Handler:   handler,
-	})
+	newNamespaceHandler = append(newNamespaceHandler, rtn)
 	c.namespaceHandler = newNamespaceHandler
+	return rtn
+}
 
-	//首次加载数据
-	handler(make(map[string]string), c.GetNamespace(namespace))
+//删除处理函数
+func (c *Conf) removeNamespaceHandler(handler *watchNamespaceHandler) {
+	c.handlerLock.Lock()
+	defer c.handlerLock.Unlock()
+	newNamespaceHandler := make([]*watchNamespaceHandler, 0)
+	for _, watchHandler := range c.namespaceHandler {
+		if watchHandler != handler {
+			newNamespaceHandler = append(newNamespaceHandler, watchHandler)
+		}
+	}
+	c.namespaceHandler = newNamespaceHandler
 }
 
 func (c *Conf) Watch(namespace string, key string, handler func(oldCfg string, newCfg string)) {
 	//加载处理函数
+	c.handlerLock.Lock()
 	newKeyHandler := make([]*watchKeyHandler, 0)
 	for _, watchHandler := range c.keyHandler {
 		newKeyHandler = append(newKeyHandler, watchHandler)
@@ -93,6 +128,7 @@ func (c *Conf) Watch(namespace string, key string, handler func(oldCfg string, n
 		Handler:   handler,
 	})
 	c.keyHandler = newKeyHandler
+	c.handlerLock.Unlock()
 
 	//首次加载数据
 	kv := c.GetNamespace(namespace)
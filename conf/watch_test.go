@@ -1,25 +1,66 @@
 package conf
 
 import (
-	"fmt"
 	"testing"
 	"time"
 )
 
 func TestWatchNamespace(t *testing.T) {
-	tConf.WatchNamespace("blacklist.url", func(oldCfgs map[string]string, newCfgs map[string]string) {
-		fmt.Println("---------------------------")
-		fmt.Println(oldCfgs)
-		fmt.Println(newCfgs)
+	srv, tConf, stop := startTestConf(t)
+	defer stop()
+
+	changed := make(chan [2]map[string]string, 2)
+	tConf.WatchNamespace(tNamespace, func(oldCfgs map[string]string, newCfgs map[string]string) {
+		changed <- [2]map[string]string{oldCfgs, newCfgs}
 	})
-	time.Sleep(time.Second*1)
+
+	//首次加载数据
+	v := <-changed
+	if len(v[0]) != 0 || v[1]["kkk"] != "vvv" {
+		t.Error("first load", v)
+	}
+
+	if !srv.WaitPoll(tNamespace, time.Second*5) {
+		t.Fatal("wait poll timeout")
+	}
+	srv.Publish(tNamespace, map[string]string{"kkk": "new"})
+	select {
+	case v := <-changed:
+		if v[0]["kkk"] != "vvv" || v[1]["kkk"] != "new" {
+			t.Error("changed", v)
+		}
+	case <-time.After(time.Second * 5):
+		t.Error("watch timeout")
+	}
 }
 
 func TestWatchKey(t *testing.T) {
-	tConf.Watch("blacklist.url", "kkk", func(oldCfg string, newCfg string) {
-		fmt.Println("---------------------------")
-		fmt.Println(oldCfg)
-		fmt.Println(newCfg)
+	srv, tConf, stop := startTestConf(t)
+	defer stop()
+
+	changed := make(chan [2]string, 2)
+	tConf.Watch(tNamespace, "kkk", func(oldCfg string, newCfg string) {
+		changed <- [2]string{oldCfg, newCfg}
 	})
-	time.Sleep(time.Second*1)
+	if v := <-changed; v != [2]string{"", "vvv"} {
+		t.Error("first load", v)
+	}
+
+	if !srv.WaitPoll(tNamespace, time.Second*5) {
+		t.Fatal("wait poll timeout")
+	}
+	//其他key的变化不会调用回调函数
+	srv.Publish(tNamespace, map[string]string{"kkk": "vvv", "other": "x"})
+	if !srv.WaitPoll(tNamespace, time.Second*5) {
+		t.Fatal("wait poll timeout")
+	}
+	srv.Publish(tNamespace, map[string]string{"other": "x"})
+	select {
+	case v := <-changed:
+		if v != [2]string{"vvv", ""} {
+			t.Error("changed", v)
+		}
+	case <-time.After(time.Second * 5):
+		t.Error("watch timeout")
+	}
 }
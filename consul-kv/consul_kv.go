@@ -35,8 +35,8 @@ func List(prefix string) ([]KVPair, error) {
 	return defaultClient.List(prefix)
 }
 
-func WatchPrefix(prefix string, handler func(_ uint64,pairs api.KVPairs)) {
-	defaultClient.WatchPrefix(prefix, handler)
+func WatchPrefix(prefix string, handler func(_ uint64,pairs api.KVPairs)) func() {
+	return defaultClient.WatchPrefix(prefix, handler)
 }
 
 type ConsulKV struct {
@@ -150,12 +150,14 @@ func (ckv *ConsulKV) List(prefix string) ([]KVPair, error) {
 	return rtn, nil
 }
 
-func (ckv *ConsulKV) WatchPrefix(prefix string, handler func(uint64, api.KVPairs)) {
+//监控前缀的变化，返回停止监控的函数
+func (ckv *ConsulKV) WatchPrefix(prefix string, handler func(uint64, api.KVPairs)) func() {
 	parse, _ := watch.Parse(map[string]interface{}{"type": "keyprefix", "prefix": prefix})
 	parse.Handler = func(u uint64, i interface{}) {
 		handler(u, i.(api.KVPairs))
 	}
 	go ckv.watch(parse, 0)
+	return parse.Stop
 }
 
 func (ckv *ConsulKV) watch(parse *watch.Plan, sentry int) {
@@ -3,17 +3,19 @@ module github.com/vrg0/go-common
 go 1.12
 
 require (
+	github.com/BurntSushi/toml v0.3.1
 	github.com/Shopify/sarama v1.23.1
 	github.com/StackExchange/wmi v0.0.0-20190523213315-cbe66965904d // indirect
 	github.com/go-ole/go-ole v1.2.4 // indirect
 	github.com/hashicorp/consul/api v1.2.0
+	github.com/hashicorp/go-hclog v1.6.3
 	github.com/natefinch/lumberjack v2.0.0+incompatible
 	github.com/pkg/errors v0.8.1
 	github.com/shima-park/agollo v1.1.0
 	github.com/shirou/gopsutil v2.18.12+incompatible
-	github.com/stretchr/testify v1.4.0 // indirect
 	go.uber.org/zap v1.10.0
 	golang.org/x/time v0.0.0-20190308202827-9d24e82272b4
 	gopkg.in/jcmturner/goidentity.v3 v3.0.0 // indirect
 	gopkg.in/natefinch/lumberjack.v2 v2.0.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -0,0 +1,188 @@
+package logger
+
+/**
+ * 适配其他日志接口
+ *
+ * StandardLoggerAt：标准库*log.Logger，写入指定等级，或根据内容推断等级
+ * SaramaLogger：sarama.StdLogger，根据内容推断等级
+ * GrpcLogger：grpclog.LoggerV2
+ * HCLogger：hclog.Logger，见hclog.go
+ * SlogHandler：slog.Handler，见slog.go(go1.21及以上)
+ */
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"log"
+	"strings"
+)
+
+//推断日志等级时使用的关键字，按顺序匹配
+var (
+	errorKeywords = []string{"error", "fail", "panic", "unable", "cannot", "can't", "refused"}
+	warnKeywords  = []string{"warn", "retry", "retrying", "timeout", "timed out", "disconnect", "abandon", "not available", "closed"}
+)
+
+//根据日志内容推断日志等级，不包含关键字时为info
+func inferLevel(msg string) zapcore.Level {
+	lower := strings.ToLower(msg)
+	for _, keyword := range errorKeywords {
+		if strings.Contains(lower, keyword) {
+			return zapcore.ErrorLevel
+		}
+	}
+	for _, keyword := range warnKeywords {
+		if strings.Contains(lower, keyword) {
+			return zapcore.WarnLevel
+		}
+	}
+	return zapcore.InfoLevel
+}
+
+//按等级写日志，DPanic及以上等级按error写入，不会panic或退出
+func logAt(sugar *zap.SugaredLogger, level zapcore.Level, msg string, keysAndValues ...interface{}) {
+	switch level {
+	case zapcore.DebugLevel:
+		sugar.Debugw(msg, keysAndValues...)
+	case zapcore.InfoLevel:
+		sugar.Infow(msg, keysAndValues...)
+	case zapcore.WarnLevel:
+		sugar.Warnw(msg, keysAndValues...)
+	default:
+		sugar.Errorw(msg, keysAndValues...)
+	}
+}
+
+//标准库日志的writer，log.Logger每次调用Write写入一条日志
+type stdWriter struct {
+	sugar *zap.SugaredLogger
+	level zapcore.Level
+	infer bool
+}
+
+func (sw *stdWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	level := sw.level
+	if sw.infer {
+		level = inferLevel(msg)
+	}
+	logAt(sw.sugar, level, msg)
+	return len(p), nil
+}
+
+//获取写入指定等级的标准库日志对象
+func (l *Logger) StandardLoggerAt(level zapcore.Level) *log.Logger {
+	return log.New(l.stdWriter(level, false), "", 0)
+}
+
+//获取根据内容推断等级的标准库日志对象，用于conf、kafka等只接受*log.Logger的模块
+func (l *Logger) InferStandardLogger() *log.Logger {
+	return log.New(l.stdWriter(zapcore.InfoLevel, true), "", 0)
+}
+
+func (l *Logger) stdWriter(level zapcore.Level, infer bool) *stdWriter {
+	return &stdWriter{
+		//跳过stdWriter.Write、logAt、log.Logger.output、log.Logger.Print
+		sugar: l.logger.WithOptions(zap.AddCallerSkip(4)).Sugar(),
+		level: level,
+		infer: infer,
+	}
+}
+
+//sarama.StdLogger，如：sarama.Logger = l.Named("sarama").SaramaLogger()
+type SaramaLogger struct {
+	sugar *zap.SugaredLogger
+}
+
+func (l *Logger) SaramaLogger() *SaramaLogger {
+	return &SaramaLogger{
+		//跳过SaramaLogger的方法和logAt
+		sugar: l.logger.WithOptions(zap.AddCallerSkip(2)).Sugar(),
+	}
+}
+
+func (sl *SaramaLogger) Print(v ...interface{}) {
+	msg := fmt.Sprint(v...)
+	logAt(sl.sugar, inferLevel(msg), msg)
+}
+
+func (sl *SaramaLogger) Printf(format string, v ...interface{}) {
+	msg := strings.TrimSuffix(fmt.Sprintf(format, v...), "\n")
+	logAt(sl.sugar, inferLevel(msg), msg)
+}
+
+func (sl *SaramaLogger) Println(v ...interface{}) {
+	msg := strings.TrimSuffix(fmt.Sprintln(v...), "\n")
+	logAt(sl.sugar, inferLevel(msg), msg)
+}
+
+//grpclog.LoggerV2，如：grpclog.SetLoggerV2(l.Named("grpc").GrpcLogger(0))
+type GrpcLogger struct {
+	sugar     *zap.SugaredLogger
+	verbosity int
+}
+
+//verbosity为V(l)返回true的最大l
+func (l *Logger) GrpcLogger(verbosity int) *GrpcLogger {
+	return &GrpcLogger{
+		sugar:     l.sugar,
+		verbosity: verbosity,
+	}
+}
+
+func sprintln(args []interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
+
+func (gl *GrpcLogger) Info(args ...interface{}) {
+	gl.sugar.Info(args...)
+}
+
+func (gl *GrpcLogger) Infoln(args ...interface{}) {
+	gl.sugar.Info(sprintln(args))
+}
+
+func (gl *GrpcLogger) Infof(format string, args ...interface{}) {
+	gl.sugar.Infof(format, args...)
+}
+
+func (gl *GrpcLogger) Warning(args ...interface{}) {
+	gl.sugar.Warn(args...)
+}
+
+func (gl *GrpcLogger) Warningln(args ...interface{}) {
+	gl.sugar.Warn(sprintln(args))
+}
+
+func (gl *GrpcLogger) Warningf(format string, args ...interface{}) {
+	gl.sugar.Warnf(format, args...)
+}
+
+func (gl *GrpcLogger) Error(args ...interface{}) {
+	gl.sugar.Error(args...)
+}
+
+func (gl *GrpcLogger) Errorln(args ...interface{}) {
+	gl.sugar.Error(sprintln(args))
+}
+
+func (gl *GrpcLogger) Errorf(format string, args ...interface{}) {
+	gl.sugar.Errorf(format, args...)
+}
+
+func (gl *GrpcLogger) Fatal(args ...interface{}) {
+	gl.sugar.Fatal(args...)
+}
+
+func (gl *GrpcLogger) Fatalln(args ...interface{}) {
+	gl.sugar.Fatal(sprintln(args))
+}
+
+func (gl *GrpcLogger) Fatalf(format string, args ...interface{}) {
+	gl.sugar.Fatalf(format, args...)
+}
+
+func (gl *GrpcLogger) V(l int) bool {
+	return l <= gl.verbosity
+}
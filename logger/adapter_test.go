@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"github.com/Shopify/sarama"
+	"github.com/hashicorp/go-hclog"
+	"go.uber.org/zap/zapcore"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//grpclog.LoggerV2
+type tGrpcLoggerV2 interface {
+	Info(args ...interface{})
+	Infoln(args ...interface{})
+	Infof(format string, args ...interface{})
+	Warning(args ...interface{})
+	Warningln(args ...interface{})
+	Warningf(format string, args ...interface{})
+	Error(args ...interface{})
+	Errorln(args ...interface{})
+	Errorf(format string, args ...interface{})
+	Fatal(args ...interface{})
+	Fatalln(args ...interface{})
+	Fatalf(format string, args ...interface{})
+	V(l int) bool
+}
+
+var (
+	_ sarama.StdLogger = (*SaramaLogger)(nil)
+	_ tGrpcLoggerV2    = (*GrpcLogger)(nil)
+)
+
+//新建json格式的日志，返回读取日志的函数
+func tAdapterLogger(t *testing.T) (*Logger, func() []map[string]interface{}) {
+	logPath := filepath.Join(tempLogDir(t), "adapter.log")
+	l := NewWithOptions(Options{Path: logPath, Level: zapcore.DebugLevel, Format: FormatJSON, Caller: true, DisableIdentity: true})
+	return l, func() []map[string]interface{} {
+		_ = l.Close()
+		return readJSONLines(t, logPath)
+	}
+}
+
+func tCheckLines(t *testing.T, lines []map[string]interface{}, levels []string, file string) {
+	if len(lines) != len(levels) {
+		t.Fatal("lines", lines)
+	}
+	for i, line := range lines {
+		if line["level"] != levels[i] {
+			t.Error("level", levels[i], line)
+		}
+		if caller, _ := line["caller"].(string); !strings.HasPrefix(caller, file) {
+			t.Error("caller", line)
+		}
+	}
+}
+
+func TestInferLevel(t *testing.T) {
+	testCase := map[string]zapcore.Level{
+		"client/metadata fetching metadata for all topics from broker": zapcore.InfoLevel,
+		"client/metadata got error from broker 1 while fetching":       zapcore.ErrorLevel,
+		"Failed to connect to broker":                                  zapcore.ErrorLevel,
+		"client/metadata retrying after 250ms":                         zapcore.WarnLevel,
+	}
+	for msg, level := range testCase {
+		if inferLevel(msg) != level {
+			t.Error(msg, level)
+		}
+	}
+}
+
+func TestStandardLoggerAt(t *testing.T) {
+	l, read := tAdapterLogger(t)
+	l.StandardLoggerAt(zapcore.WarnLevel).Print("test standard warn")
+	l.InferStandardLogger().Printf("test standard %s", "failed")
+	tCheckLines(t, read(), []string{"warn", "error"}, "logger/adapter_test.go")
+}
+
+func TestSaramaLogger(t *testing.T) {
+	l, read := tAdapterLogger(t)
+	sl := l.SaramaLogger()
+	sl.Print("test sarama info")
+	sl.Printf("test sarama %s\n", "retrying")
+	sl.Println("test sarama", "error")
+	lines := read()
+	tCheckLines(t, lines, []string{"info", "warn", "error"}, "logger/adapter_test.go")
+	if lines[2]["msg"] != "test sarama error" {
+		t.Error("println", lines[2])
+	}
+}
+
+func TestGrpcLogger(t *testing.T) {
+	l, read := tAdapterLogger(t)
+	gl := l.GrpcLogger(1)
+	gl.Infoln("test grpc", "info")
+	gl.Warningf("test grpc %s", "warn")
+	gl.Error("test grpc error")
+	if !gl.V(1) || gl.V(2) {
+		t.Error("verbosity")
+	}
+	tCheckLines(t, read(), []string{"info", "warn", "error"}, "logger/adapter_test.go")
+}
+
+func TestHCLogger(t *testing.T) {
+	l, read := tAdapterLogger(t)
+	var hl hclog.Logger = l.HCLogger()
+	hl = hl.Named("consul").With("k1", "v1")
+	hl.Trace("test hclog trace")
+	hl.Named("agent").Warn("test hclog warn", "k2", 2)
+	hl.Log(hclog.Error, "test hclog error")
+	hl.StandardLogger(&hclog.StandardLoggerOptions{InferLevels: true}).Print("[ERR] test hclog standard")
+	if hl.Name() != "consul" || hl.ResetNamed("raft").Name() != "raft" || len(hl.ImpliedArgs()) != 2 {
+		t.Error("name", hl.Name())
+	}
+
+	hl.SetLevel(hclog.Warn)
+	if hl.GetLevel() != hclog.Warn || hl.IsInfo() || !hl.IsWarn() {
+		t.Error("level", hl.GetLevel())
+	}
+	hl.Info("test hclog info")
+
+	lines := read()
+	tCheckLines(t, lines, []string{"debug", "warn", "error", "error"}, "logger/adapter_test.go")
+	if lines[1]["logger"] != "consul.agent" || lines[1]["k1"] != "v1" || lines[1]["k2"] != float64(2) {
+		t.Error("named", lines[1])
+	}
+	if lines[3]["msg"] != "test hclog standard" || lines[3]["logger"] != "consul" {
+		t.Error("standard", lines[3])
+	}
+}
+
+func TestHCLogPrefixLevel(t *testing.T) {
+	testCase := []struct {
+		msg       string
+		timestamp bool
+		level     zapcore.Level
+		rtn       string
+	}{
+		{"[ERROR] x", false, zapcore.ErrorLevel, "x"},
+		{"[ERR] x", false, zapcore.ErrorLevel, "x"},
+		{"[INFO] retry after [ERROR] x", false, zapcore.InfoLevel, "retry after [ERROR] x"},
+		{"retry after [ERROR] x", false, zapcore.WarnLevel, "retry after [ERROR] x"},
+		{"2019/01/02 15:04:05 [DEBUG] x", false, zapcore.WarnLevel, "2019/01/02 15:04:05 [DEBUG] x"},
+		{"2019/01/02 15:04:05 [DEBUG] x", true, zapcore.DebugLevel, "x"},
+		{"2019-01-02T15:04:05.000+0800 [WARN]  x [ERROR]", true, zapcore.WarnLevel, "x [ERROR]"},
+	}
+	for _, c := range testCase {
+		level, rtn := hclogPrefixLevel(c.msg, zapcore.WarnLevel, c.timestamp)
+		if level != c.level || rtn != c.rtn {
+			t.Error(c.msg, level, rtn)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package logger
+
+/**
+ * 异步写日志
+ *
+ * 日志先写入有界的环形队列，由后台协程批量写入，避免磁盘缓慢时阻塞调用方
+ * 队列满时根据策略阻塞、丢弃新日志或丢弃最旧的日志，丢弃的条数可以通过Dropped获取
+ * Sync会等待队列中的日志全部写入，Close会写入剩余的日志并停止后台协程
+ */
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+//队列满时的处理策略
+const (
+	AsyncBlock      = "block"       //阻塞直到队列有空位
+	AsyncDropNewest = "drop_newest" //丢弃新的日志
+	AsyncDropOldest = "drop_oldest" //丢弃最旧的日志
+)
+
+//异步写日志的配置
+type AsyncOptions struct {
+	QueueSize int    //队列长度，为0时为8192
+	BatchSize int    //每次批量写入的最大条数，为0时为128
+	Policy    string //队列满时的处理策略，为空时为AsyncBlock
+}
+
+type asyncWriter struct {
+	writer   io.Writer
+	policy   string
+	batch    int
+	lock     *sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	idle     *sync.Cond //队列为空并且没有正在写入的日志
+	queue    [][]byte   //环形队列
+	head     int
+	size     int
+	writing  bool
+	closed   bool
+	dropped  uint64
+	done     chan struct{}
+}
+
+func newAsyncWriter(w io.Writer, opts AsyncOptions) *asyncWriter {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 8192
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 128
+	}
+	if opts.Policy == "" {
+		opts.Policy = AsyncBlock
+	}
+
+	rtn := &asyncWriter{
+		writer: w,
+		policy: opts.Policy,
+		batch:  opts.BatchSize,
+		lock:   new(sync.Mutex),
+		queue:  make([][]byte, opts.QueueSize),
+		done:   make(chan struct{}),
+	}
+	rtn.notEmpty = sync.NewCond(rtn.lock)
+	rtn.notFull = sync.NewCond(rtn.lock)
+	rtn.idle = sync.NewCond(rtn.lock)
+
+	go rtn.run()
+
+	return rtn
+}
+
+func (aw *asyncWriter) Write(p []byte) (int, error) {
+	//zap会复用p，需要拷贝
+	data := make([]byte, len(p))
+	copy(data, p)
+
+	aw.lock.Lock()
+	defer aw.lock.Unlock()
+
+	if aw.closed {
+		return 0, errors.New("async writer closed")
+	}
+
+	if aw.size == len(aw.queue) {
+		switch aw.policy {
+		case AsyncDropNewest:
+			atomic.AddUint64(&aw.dropped, 1)
+			return len(p), nil
+		case AsyncDropOldest:
+			aw.queue[aw.head] = nil
+			aw.head = (aw.head + 1) % len(aw.queue)
+			aw.size--
+			atomic.AddUint64(&aw.dropped, 1)
+		default:
+			for aw.size == len(aw.queue) && !aw.closed {
+				aw.notFull.Wait()
+			}
+			if aw.closed {
+				return 0, errors.New("async writer closed")
+			}
+		}
+	}
+
+	aw.queue[(aw.head+aw.size)%len(aw.queue)] = data
+	aw.size++
+	aw.notEmpty.Signal()
+
+	return len(p), nil
+}
+
+//后台协程，批量写入队列中的日志
+func (aw *asyncWriter) run() {
+	defer close(aw.done)
+
+	batch := make([]byte, 0, 4096)
+	for {
+		aw.lock.Lock()
+		for aw.size == 0 && !aw.closed {
+			aw.notEmpty.Wait()
+		}
+		if aw.size == 0 && aw.closed {
+			aw.lock.Unlock()
+			return
+		}
+
+		batch = batch[:0]
+		for i := 0; i < aw.batch && aw.size > 0; i++ {
+			batch = append(batch, aw.queue[aw.head]...)
+			aw.queue[aw.head] = nil
+			aw.head = (aw.head + 1) % len(aw.queue)
+			aw.size--
+		}
+		aw.writing = true
+		aw.notFull.Broadcast()
+		aw.lock.Unlock()
+
+		_, _ = aw.writer.Write(batch)
+
+		aw.lock.Lock()
+		aw.writing = false
+		if aw.size == 0 {
+			aw.idle.Broadcast()
+		}
+		aw.lock.Unlock()
+	}
+}
+
+//等待队列中的日志全部写入
+func (aw *asyncWriter) Sync() error {
+	aw.lock.Lock()
+	for (aw.size > 0 || aw.writing) && !aw.closed {
+		aw.idle.Wait()
+	}
+	aw.lock.Unlock()
+
+	return syncWriter(aw.writer)
+}
+
+//写入剩余的日志并停止后台协程
+func (aw *asyncWriter) Close() error {
+	aw.lock.Lock()
+	if aw.closed {
+		aw.lock.Unlock()
+		return nil
+	}
+	aw.closed = true
+	aw.notEmpty.Broadcast()
+	aw.notFull.Broadcast()
+	aw.idle.Broadcast()
+	aw.lock.Unlock()
+
+	<-aw.done
+	return nil
+}
+
+//丢弃的日志条数
+func (aw *asyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&aw.dropped)
+}
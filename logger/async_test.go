@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"bytes"
+	"go.uber.org/zap/zapcore"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+//写入前等待gate关闭的writer
+type tGateWriter struct {
+	gate chan struct{}
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (w *tGateWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *tGateWriter) String() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	w := &tGateWriter{gate: make(chan struct{})}
+	close(w.gate)
+	aw := newAsyncWriter(w, AsyncOptions{QueueSize: 4, BatchSize: 2})
+
+	for _, line := range []string{"a\n", "b\n", "c\n", "d\n", "e\n"} {
+		_, _ = aw.Write([]byte(line))
+	}
+	if err := aw.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if w.String() != "a\nb\nc\nd\ne\n" {
+		t.Error("sync", w.String())
+	}
+
+	_ = aw.Close()
+	if _, err := aw.Write([]byte("f\n")); err == nil {
+		t.Error("write after close")
+	}
+}
+
+func TestAsyncDropPolicy(t *testing.T) {
+	for policy, expect := range map[string]string{
+		AsyncDropNewest: "0\n1\n2\n",
+		AsyncDropOldest: "0\n3\n4\n",
+	} {
+		w := &tGateWriter{gate: make(chan struct{})}
+		aw := newAsyncWriter(w, AsyncOptions{QueueSize: 2, BatchSize: 1, Policy: policy})
+
+		//第一条被后台协程取出后阻塞在gate上，队列中最多保存2条
+		_, _ = aw.Write([]byte("0\n"))
+		for {
+			aw.lock.Lock()
+			writing := aw.writing
+			aw.lock.Unlock()
+			if writing {
+				break
+			}
+		}
+		for _, line := range []string{"1\n", "2\n", "3\n", "4\n"} {
+			_, _ = aw.Write([]byte(line))
+		}
+
+		close(w.gate)
+		_ = aw.Close()
+		if w.String() != expect || aw.Dropped() != 2 {
+			t.Error(policy, w.String(), aw.Dropped())
+		}
+	}
+}
+
+func TestAsyncLogger(t *testing.T) {
+	dir := tempLogDir(t)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	path := filepath.Join(dir, "async.log")
+	l := NewWithOptions(Options{
+		Path:  path,
+		Level: zapcore.DebugLevel,
+		Async: &AsyncOptions{QueueSize: 16},
+	})
+	for i := 0; i < 100; i++ {
+		l.Infof("test async %d", i)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	if n := bytes.Count(data, []byte("\n")); n != 100 || l.Dropped() != 0 {
+		t.Error("async lines", n, l.Dropped())
+	}
+}
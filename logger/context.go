@@ -0,0 +1,23 @@
+package logger
+
+import (
+	"context"
+)
+
+type contextKey struct{}
+
+//把Logger存入context
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+//从context中获取Logger，不存在时返回nil
+func FromContext(ctx context.Context) *Logger {
+	if ctx == nil {
+		return nil
+	}
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package logger
+
+import (
+	"context"
+	"go.uber.org/zap/zapcore"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	if FromContext(context.Background()) != nil {
+		t.Error("from empty context")
+	}
+
+	l := New("/dev/stdout", zapcore.DebugLevel).With("request_id", "abc")
+	ctx := NewContext(context.Background(), l)
+	if FromContext(ctx) != l {
+		t.Error("from context")
+	}
+}
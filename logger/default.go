@@ -0,0 +1,196 @@
+package logger
+
+/**
+ * 默认的Logger
+ *
+ * init时读取args中的env和log_path参数：
+ * env为"dev"(默认值)时，日志默认打印到stdout，日志等级为debug
+ * env不为"dev"时，日志默认打印到os.Args[0]+".log"，日志等级为info
+ * log_path存在时使用log_path作为日志路径
+ *
+ * 包级别的函数使用默认的Logger，可以通过ResetDefaultLogger或SetDefault并发安全地替换
+ */
+
+import (
+	"github.com/vrg0/go-common/args"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"log"
+	"os"
+	"sync"
+)
+
+var (
+	defaultLogger     *Logger
+	defaultLoggerLock = new(sync.RWMutex)
+)
+
+func init() {
+	defaultLogger = New(defaultPathAndLevel())
+}
+
+//根据args中的env和log_path参数获取默认的日志路径和日志等级
+func defaultPathAndLevel() (string, zapcore.Level) {
+	logPath, level := "/dev/stdout", zapcore.DebugLevel
+	if args.GetOrDefault("env", "dev") != "dev" {
+		logPath, level = os.Args[0]+".log", zapcore.InfoLevel
+	}
+	if path, ok := args.Get("log_path"); ok && path != "" {
+		logPath = path
+	}
+	return logPath, level
+}
+
+//获取默认的Logger
+func Default() *Logger {
+	defaultLoggerLock.RLock()
+	defer defaultLoggerLock.RUnlock()
+	return defaultLogger
+}
+
+//替换默认的Logger，返回被替换的Logger，l为nil时不替换
+//被替换的Logger不会被关闭，可能仍有协程在使用，需要时由调用方关闭
+func SetDefault(l *Logger) *Logger {
+	defaultLoggerLock.Lock()
+	defer defaultLoggerLock.Unlock()
+	rtn := defaultLogger
+	if l != nil {
+		defaultLogger = l
+	}
+	return rtn
+}
+
+//重新设置默认的Logger，logPath为空时打印到stdout
+//hook不会从原来的Logger中继承，需要重新添加
+func ResetDefaultLogger(logPath string, level zapcore.Level) {
+	SetDefault(New(logPath, level))
+}
+
+//添加默认Logger编码后日志的hook
+func SetHookFunc(hookFunc HookFunc) *HookHandle {
+	return Default().SetHookFunc(hookFunc)
+}
+
+//添加默认Logger结构化日志的hook
+func AddHook(level zapcore.Level, hook EntryHookFunc) *HookHandle {
+	return Default().AddHook(level, hook)
+}
+
+//修改默认Logger的日志等级
+func SetLevel(level zapcore.Level) {
+	Default().SetLevel(level)
+}
+
+//获取默认Logger的日志等级
+func GetLevel() zapcore.Level {
+	return Default().GetLevel()
+}
+
+func GetStandardLogger() *log.Logger {
+	return Default().GetStandardLogger()
+}
+
+func GetSugaredLogger() *zap.SugaredLogger {
+	return Default().GetSugaredLogger()
+}
+
+//派生出带有字段的Logger
+func With(keysAndValues ...interface{}) *Logger {
+	return Default().With(keysAndValues...)
+}
+
+//派生出带有名称的Logger
+func Named(name string) *Logger {
+	return Default().Named(name)
+}
+
+//写入默认Logger缓存中的日志
+func Sync() error {
+	return Default().Sync()
+}
+
+//以下函数直接调用sugar，与Logger的方法有相同的调用层数，使caller指向调用方
+
+func Debug(args ...interface{}) {
+	Default().sugar.Debug(args...)
+}
+
+func Info(args ...interface{}) {
+	Default().sugar.Info(args...)
+}
+
+func Warn(args ...interface{}) {
+	Default().sugar.Warn(args...)
+}
+
+func Error(args ...interface{}) {
+	Default().sugar.Error(args...)
+}
+
+func DPanic(args ...interface{}) {
+	Default().sugar.DPanic(args...)
+}
+
+func Panic(args ...interface{}) {
+	Default().sugar.Panic(args...)
+}
+
+func Fatal(args ...interface{}) {
+	Default().sugar.Fatal(args...)
+}
+
+func Debugf(template string, args ...interface{}) {
+	Default().sugar.Debugf(template, args...)
+}
+
+func Infof(template string, args ...interface{}) {
+	Default().sugar.Infof(template, args...)
+}
+
+func Warnf(template string, args ...interface{}) {
+	Default().sugar.Warnf(template, args...)
+}
+
+func Errorf(template string, args ...interface{}) {
+	Default().sugar.Errorf(template, args...)
+}
+
+func DPanicf(template string, args ...interface{}) {
+	Default().sugar.DPanicf(template, args...)
+}
+
+func Panicf(template string, args ...interface{}) {
+	Default().sugar.Panicf(template, args...)
+}
+
+func Fatalf(template string, args ...interface{}) {
+	Default().sugar.Fatalf(template, args...)
+}
+
+func Debugw(msg string, keysAndValues ...interface{}) {
+	Default().sugar.Debugw(msg, keysAndValues...)
+}
+
+func Infow(msg string, keysAndValues ...interface{}) {
+	Default().sugar.Infow(msg, keysAndValues...)
+}
+
+func Warnw(msg string, keysAndValues ...interface{}) {
+	Default().sugar.Warnw(msg, keysAndValues...)
+}
+
+func Errorw(msg string, keysAndValues ...interface{}) {
+	Default().sugar.Errorw(msg, keysAndValues...)
+}
+
+func DPanicw(msg string, keysAndValues ...interface{}) {
+	Default().sugar.DPanicw(msg, keysAndValues...)
+}
+
+func Panicw(msg string, keysAndValues ...interface{}) {
+	Default().sugar.Panicw(msg, keysAndValues...)
+}
+
+func Fatalw(msg string, keysAndValues ...interface{}) {
+	Default().sugar.Fatalw(msg, keysAndValues...)
+}
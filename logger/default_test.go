@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"github.com/vrg0/go-common/args"
+	"go.uber.org/zap/zapcore"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDefaultPathAndLevel(t *testing.T) {
+	restoreEnv := args.Override("env", "dev")
+	restorePath := args.Override("log_path")
+	if logPath, level := defaultPathAndLevel(); logPath != "/dev/stdout" || level != zapcore.DebugLevel {
+		t.Error("dev", logPath, level)
+	}
+
+	args.Set("env", "prod")
+	if logPath, level := defaultPathAndLevel(); logPath != os.Args[0]+".log" || level != zapcore.InfoLevel {
+		t.Error("prod", logPath, level)
+	}
+
+	args.Set("log_path", "/tmp/test_default.log")
+	if logPath, _ := defaultPathAndLevel(); logPath != "/tmp/test_default.log" {
+		t.Error("log_path", logPath)
+	}
+	restorePath()
+	restoreEnv()
+}
+
+func TestSetDefault(t *testing.T) {
+	logPath := filepath.Join(tempLogDir(t), "default.log")
+	old := SetDefault(NewWithOptions(Options{Path: logPath, Level: zapcore.InfoLevel, Caller: true}))
+	defer SetDefault(old)
+
+	Debug("test default debug")
+	Infow("test default info", "k1", "v1")
+	Named("child").Warnf("test default %s", "warn")
+	_ = Default().Close()
+
+	data, err := ioutil.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatal("lines", lines)
+	}
+	if !strings.Contains(lines[0], "logger/default_test.go") || !strings.Contains(lines[0], "test default info") {
+		t.Error("caller", lines[0])
+	}
+	if !strings.Contains(lines[1], "child") || !strings.Contains(lines[1], "test default warn") {
+		t.Error("named", lines[1])
+	}
+}
+
+func TestResetDefaultLogger(t *testing.T) {
+	old := Default()
+	defer SetDefault(old)
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Debug("test reset default")
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		ResetDefaultLogger("/dev/stdout", zapcore.InfoLevel)
+	}
+	wg.Wait()
+
+	if Default() == old || GetLevel() != zapcore.InfoLevel {
+		t.Error("reset default logger")
+	}
+}
+
+//通过ARGS_ENV_PREFIX设置前缀后，默认Logger不受没有前缀的ENV、LOG_PATH影响
+func TestDefaultEnvPrefix(t *testing.T) {
+	if logPath := os.Getenv("TEST_ENV_PREFIX_LOG_PATH"); logPath != "" {
+		if level := GetLevel(); level != zapcore.DebugLevel {
+			t.Fatal("level", level)
+		}
+		Info("test env prefix")
+		_ = Sync()
+		data, err := ioutil.ReadFile(logPath)
+		if err != nil || !strings.Contains(string(data), "test env prefix") {
+			t.Fatal("log path", string(data), err)
+		}
+		return
+	}
+
+	dir := tempLogDir(t)
+	logPath := filepath.Join(dir, "prefix.log")
+	cmd := exec.Command(os.Args[0], "-test.run=TestDefaultEnvPrefix")
+	cmd.Env = append(os.Environ(),
+		"TEST_ENV_PREFIX_LOG_PATH="+logPath,
+		args.EnvPrefixEnv+"=MYAPP_",
+		"ENV=prod",
+		"LOG_PATH="+filepath.Join(dir, "injected.log"),
+		"MYAPP_LOG_PATH="+logPath,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatal(err, string(out))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "injected.log")); !os.IsNotExist(err) {
+		t.Error("injected LOG_PATH used", err)
+	}
+}
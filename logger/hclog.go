@@ -0,0 +1,230 @@
+package logger
+
+import (
+	"github.com/hashicorp/go-hclog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+)
+
+//hclog.Logger，如：consul、raft等hashicorp的库
+//SetLevel修改的是Logger的日志等级，对共享等级的所有Logger有效
+type HCLogger struct {
+	logger  *Logger
+	base    *zap.Logger //没有添加hclog名称和字段的zap.Logger，用于ResetNamed
+	sugar   *zap.SugaredLogger
+	name    string
+	implied []interface{}
+}
+
+var _ hclog.Logger = (*HCLogger)(nil)
+
+func (l *Logger) HCLogger() *HCLogger {
+	//跳过HCLogger的方法和logAt
+	base := l.logger.WithOptions(zap.AddCallerSkip(2))
+	return &HCLogger{
+		logger:  l,
+		base:    base,
+		sugar:   base.Sugar(),
+		implied: make([]interface{}, 0),
+	}
+}
+
+func hclogLevel(level hclog.Level) (zapcore.Level, bool) {
+	switch level {
+	case hclog.Trace, hclog.Debug:
+		return zapcore.DebugLevel, true
+	case hclog.NoLevel, hclog.Info:
+		return zapcore.InfoLevel, true
+	case hclog.Warn:
+		return zapcore.WarnLevel, true
+	case hclog.Error:
+		return zapcore.ErrorLevel, true
+	default:
+		return zapcore.FatalLevel + 1, false
+	}
+}
+
+func (hl *HCLogger) Log(level hclog.Level, msg string, args ...interface{}) {
+	if lv, ok := hclogLevel(level); ok {
+		logAt(hl.sugar, lv, msg, args...)
+	}
+}
+
+func (hl *HCLogger) Trace(msg string, args ...interface{}) {
+	logAt(hl.sugar, zapcore.DebugLevel, msg, args...)
+}
+
+func (hl *HCLogger) Debug(msg string, args ...interface{}) {
+	logAt(hl.sugar, zapcore.DebugLevel, msg, args...)
+}
+
+func (hl *HCLogger) Info(msg string, args ...interface{}) {
+	logAt(hl.sugar, zapcore.InfoLevel, msg, args...)
+}
+
+func (hl *HCLogger) Warn(msg string, args ...interface{}) {
+	logAt(hl.sugar, zapcore.WarnLevel, msg, args...)
+}
+
+func (hl *HCLogger) Error(msg string, args ...interface{}) {
+	logAt(hl.sugar, zapcore.ErrorLevel, msg, args...)
+}
+
+func (hl *HCLogger) enabled(level zapcore.Level) bool {
+	return hl.base.Core().Enabled(level)
+}
+
+func (hl *HCLogger) IsTrace() bool {
+	return hl.enabled(zapcore.DebugLevel)
+}
+
+func (hl *HCLogger) IsDebug() bool {
+	return hl.enabled(zapcore.DebugLevel)
+}
+
+func (hl *HCLogger) IsInfo() bool {
+	return hl.enabled(zapcore.InfoLevel)
+}
+
+func (hl *HCLogger) IsWarn() bool {
+	return hl.enabled(zapcore.WarnLevel)
+}
+
+func (hl *HCLogger) IsError() bool {
+	return hl.enabled(zapcore.ErrorLevel)
+}
+
+func (hl *HCLogger) ImpliedArgs() []interface{} {
+	return hl.implied
+}
+
+func (hl *HCLogger) With(args ...interface{}) hclog.Logger {
+	implied := make([]interface{}, 0, len(hl.implied)+len(args))
+	implied = append(implied, hl.implied...)
+	implied = append(implied, args...)
+	return &HCLogger{
+		logger:  hl.logger,
+		base:    hl.base,
+		sugar:   hl.sugar.With(args...),
+		name:    hl.name,
+		implied: implied,
+	}
+}
+
+func (hl *HCLogger) Name() string {
+	return hl.name
+}
+
+func (hl *HCLogger) Named(name string) hclog.Logger {
+	if hl.name != "" {
+		name = hl.name + "." + name
+	}
+	return hl.ResetNamed(name)
+}
+
+func (hl *HCLogger) ResetNamed(name string) hclog.Logger {
+	return &HCLogger{
+		logger:  hl.logger,
+		base:    hl.base,
+		sugar:   hl.base.Named(name).Sugar().With(hl.implied...),
+		name:    name,
+		implied: hl.implied,
+	}
+}
+
+func (hl *HCLogger) SetLevel(level hclog.Level) {
+	lv, _ := hclogLevel(level)
+	hl.logger.SetLevel(lv)
+}
+
+func (hl *HCLogger) GetLevel() hclog.Level {
+	switch level := hl.logger.GetLevel(); {
+	case level <= zapcore.DebugLevel:
+		return hclog.Debug
+	case level == zapcore.InfoLevel:
+		return hclog.Info
+	case level == zapcore.WarnLevel:
+		return hclog.Warn
+	case level <= zapcore.FatalLevel:
+		return hclog.Error
+	default:
+		return hclog.Off
+	}
+}
+
+func (hl *HCLogger) StandardLogger(opts *hclog.StandardLoggerOptions) *log.Logger {
+	return log.New(hl.StandardWriter(opts), "", 0)
+}
+
+func (hl *HCLogger) StandardWriter(opts *hclog.StandardLoggerOptions) io.Writer {
+	if opts == nil {
+		opts = &hclog.StandardLoggerOptions{}
+	}
+	level := zapcore.InfoLevel
+	if opts.ForceLevel != hclog.NoLevel {
+		level, _ = hclogLevel(opts.ForceLevel)
+	}
+	sugar := hl.base
+	if hl.name != "" {
+		sugar = sugar.Named(hl.name)
+	}
+	return &hclogWriter{
+		//跳过log.Logger.output、log.Logger.Print
+		sugar: sugar.WithOptions(zap.AddCallerSkip(2)).Sugar().With(hl.implied...),
+		level:     level,
+		infer:     opts.InferLevels || opts.InferLevelsWithTimestamp,
+		timestamp: opts.InferLevelsWithTimestamp,
+	}
+}
+
+//hclog的标准库日志，根据"[ERROR]"等前缀推断日志等级
+type hclogWriter struct {
+	sugar     *zap.SugaredLogger
+	level     zapcore.Level
+	infer     bool
+	timestamp bool //前缀之前是否有时间戳
+}
+
+func (hw *hclogWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	level := hw.level
+	if hw.infer {
+		level, msg = hclogPrefixLevel(msg, level, hw.timestamp)
+	}
+	logAt(hw.sugar, level, msg)
+	return len(p), nil
+}
+
+//hclog日志等级的前缀，按顺序匹配
+var hclogPrefixes = []struct {
+	prefix string
+	level  zapcore.Level
+}{
+	{"[TRACE]", zapcore.DebugLevel},
+	{"[DEBUG]", zapcore.DebugLevel},
+	{"[INFO]", zapcore.InfoLevel},
+	{"[WARN]", zapcore.WarnLevel},
+	{"[ERROR]", zapcore.ErrorLevel},
+	{"[ERR]", zapcore.ErrorLevel},
+}
+
+//日志开头的时间戳，如：2006/01/02 15:04:05、2006-01-02T15:04:05.000Z
+var hclogTimestampRegexp = regexp.MustCompile(`^[\d\s:/.+\-TZ]*`)
+
+//解析开头的"[ERROR] msg"格式的前缀，timestamp为true时跳过前缀之前的时间戳，没有前缀时返回level和原来的msg
+func hclogPrefixLevel(msg string, level zapcore.Level, timestamp bool) (zapcore.Level, string) {
+	str := msg
+	if timestamp {
+		str = str[len(hclogTimestampRegexp.FindString(str)):]
+	}
+	for _, p := range hclogPrefixes {
+		if strings.HasPrefix(str, p.prefix) {
+			return p.level, strings.TrimSpace(str[len(p.prefix):])
+		}
+	}
+	return level, msg
+}
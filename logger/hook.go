@@ -1,41 +1,271 @@
 package logger
 
+/**
+ * 日志hook
+ *
+ * HookFunc：接收编码后的日志，返回false时不写入主输出
+ * EntryHookFunc：接收结构化的日志，可以按等级过滤，不影响日志的写入
+ * 添加hook时返回*HookHandle，调用Remove注销hook，添加、注销hook和写日志可以并发进行
+ */
+
 import (
+	"go.uber.org/zap/zapcore"
 	"io"
+	"os"
+	"sync"
+	"time"
 )
 
 //当返回false时，则不会调用hw.writer.Writer()
 type HookFunc func(data []byte) bool
 
+//结构化的日志
+type Entry struct {
+	Level      zapcore.Level
+	Time       time.Time
+	LoggerName string
+	Message    string
+	Caller     string                 //调用位置，Options.Caller为false时为空
+	Stack      string                 //调用栈，未开启Options.Stacktrace时为空
+	Fields     map[string]interface{} //With和Infow等方法传入的字段
+}
+
+//结构化日志的hook，entry在hook返回后不能再使用
+type EntryHookFunc func(entry *Entry)
+
+//hook的句柄，用于注销hook
+type HookHandle struct {
+	once   *sync.Once
+	remove func()
+}
+
+//注销hook，可以重复调用
+func (h *HookHandle) Remove() {
+	h.once.Do(h.remove)
+}
+
+func newHookHandle(remove func()) *HookHandle {
+	return &HookHandle{
+		once:   new(sync.Once),
+		remove: remove,
+	}
+}
+
 type hookWriter struct {
-	writer           io.Writer
-	hookFuncList     []HookFunc
+	writer       io.Writer
+	lock         *sync.RWMutex
+	hookFuncList []*HookFunc
 }
 
 func NewHookWriter(w io.Writer) *hookWriter {
 	return &hookWriter{
-		writer:           w,
-		hookFuncList:     make([]HookFunc, 0),
+		writer:       w,
+		lock:         new(sync.RWMutex),
+		hookFuncList: make([]*HookFunc, 0),
 	}
 }
 
 func (hw *hookWriter) Write(p []byte) (n int, err error) {
-	if len(hw.hookFuncList) != 0 {
-		for _, handler := range hw.hookFuncList {
-			if !handler(p) {
-				return
-			}
+	hw.lock.RLock()
+	hookFuncList := hw.hookFuncList
+	hw.lock.RUnlock()
+
+	for _, handler := range hookFuncList {
+		if !(*handler)(p) {
+			return
 		}
 	}
 
 	return hw.writer.Write(p)
 }
 
-func (hw *hookWriter) AddHookFunc(hookFunc HookFunc) {
-	newFuncList := make([]HookFunc, 0)
-	for _, handler := range hw.hookFuncList {
-		newFuncList = append(newFuncList, handler)
-	}
-	newFuncList = append(newFuncList, hookFunc)
+//添加hook，返回的句柄用于注销hook
+func (hw *hookWriter) AddHookFunc(hookFunc HookFunc) *HookHandle {
+	handler := &hookFunc
+
+	hw.lock.Lock()
+	newFuncList := make([]*HookFunc, 0, len(hw.hookFuncList)+1)
+	newFuncList = append(newFuncList, hw.hookFuncList...)
+	newFuncList = append(newFuncList, handler)
 	hw.hookFuncList = newFuncList
+	hw.lock.Unlock()
+
+	return newHookHandle(func() {
+		hw.lock.Lock()
+		defer hw.lock.Unlock()
+		newFuncList := make([]*HookFunc, 0, len(hw.hookFuncList))
+		for _, h := range hw.hookFuncList {
+			if h != handler {
+				newFuncList = append(newFuncList, h)
+			}
+		}
+		hw.hookFuncList = newFuncList
+	})
+}
+
+func (hw *hookWriter) Sync() error {
+	return syncWriter(hw.writer)
+}
+
+//调用w的Sync，stdout和stderr为管道或终端时Sync会返回EINVAL，不需要Sync
+func syncWriter(w io.Writer) error {
+	if w == os.Stdout || w == os.Stderr {
+		return nil
+	}
+	if syncer, ok := w.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
+
+type entryHook struct {
+	level zapcore.Level
+	hook  EntryHookFunc
+}
+
+//结构化日志的hook列表，由Logger及其派生的Logger共享
+type entryHooks struct {
+	lock  *sync.RWMutex
+	hooks []*entryHook
+}
+
+func newEntryHooks() *entryHooks {
+	return &entryHooks{
+		lock:  new(sync.RWMutex),
+		hooks: make([]*entryHook, 0),
+	}
+}
+
+func (eh *entryHooks) add(level zapcore.Level, hook EntryHookFunc) *HookHandle {
+	h := &entryHook{level: level, hook: hook}
+
+	eh.lock.Lock()
+	newHooks := make([]*entryHook, 0, len(eh.hooks)+1)
+	newHooks = append(newHooks, eh.hooks...)
+	newHooks = append(newHooks, h)
+	eh.hooks = newHooks
+	eh.lock.Unlock()
+
+	return newHookHandle(func() {
+		eh.lock.Lock()
+		defer eh.lock.Unlock()
+		newHooks := make([]*entryHook, 0, len(eh.hooks))
+		for _, v := range eh.hooks {
+			if v != h {
+				newHooks = append(newHooks, v)
+			}
+		}
+		eh.hooks = newHooks
+	})
+}
+
+func (eh *entryHooks) list() []*entryHook {
+	eh.lock.RLock()
+	defer eh.lock.RUnlock()
+	return eh.hooks
+}
+
+//是否有hook需要此等级的日志
+func (eh *entryHooks) enabled(level zapcore.Level) bool {
+	for _, h := range eh.list() {
+		if level >= h.level {
+			return true
+		}
+	}
+	return false
+}
+
+//调用结构化日志的hook，hook的panic不会影响写日志
+func (eh *entryHooks) call(ent zapcore.Entry, fields []zapcore.Field) {
+	hooks := eh.list()
+	var entry *Entry
+	for _, h := range hooks {
+		if ent.Level < h.level {
+			continue
+		}
+		if entry == nil {
+			entry = newEntry(ent, fields)
+		}
+		func() {
+			defer func() {
+				_ = recover()
+			}()
+			h.hook(entry)
+		}()
+	}
+}
+
+func newEntry(ent zapcore.Entry, fields []zapcore.Field) *Entry {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	rtn := &Entry{
+		Level:      ent.Level,
+		Time:       ent.Time,
+		LoggerName: ent.LoggerName,
+		Message:    ent.Message,
+		Stack:      ent.Stack,
+		Fields:     enc.Fields,
+	}
+	if ent.Caller.Defined {
+		rtn.Caller = ent.Caller.TrimmedPath()
+	}
+	return rtn
+}
+
+//在zapcore.Core之外调用结构化日志的hook
+type hookCore struct {
+	zapcore.Core
+	hooks  *entryHooks
+	fields []zapcore.Field //With添加的字段
+}
+
+func newHookCore(core zapcore.Core, hooks *entryHooks) zapcore.Core {
+	return &hookCore{
+		Core:   core,
+		hooks:  hooks,
+		fields: make([]zapcore.Field, 0),
+	}
+}
+
+func (hc *hookCore) Enabled(level zapcore.Level) bool {
+	return hc.Core.Enabled(level) || hc.hooks.enabled(level)
+}
+
+func (hc *hookCore) With(fields []zapcore.Field) zapcore.Core {
+	newFields := make([]zapcore.Field, 0, len(hc.fields)+len(fields))
+	newFields = append(newFields, hc.fields...)
+	newFields = append(newFields, fields...)
+	return &hookCore{
+		Core:   hc.Core.With(fields),
+		hooks:  hc.hooks,
+		fields: newFields,
+	}
+}
+
+func (hc *hookCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	ce = hc.Core.Check(ent, ce)
+	if hc.hooks.enabled(ent.Level) {
+		ce = ce.AddCore(ent, &hookDispatcher{hc})
+	}
+	return ce
+}
+
+//只调用hook，不写日志
+type hookDispatcher struct {
+	*hookCore
+}
+
+func (hd *hookDispatcher) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	allFields := make([]zapcore.Field, 0, len(hd.fields)+len(fields))
+	allFields = append(allFields, hd.fields...)
+	allFields = append(allFields, fields...)
+	hd.hooks.call(ent, allFields)
+	return nil
+}
+
+func (hd *hookDispatcher) Sync() error {
+	return nil
 }
@@ -1,8 +1,12 @@
 package logger
 
 import (
+	"go.uber.org/zap/zapcore"
 	"io"
+	"io/ioutil"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -14,3 +18,92 @@ func TestWatchWriter(t *testing.T) {
 	})
 	_, _ = io.WriteString(ww, "test AddHookFunc")
 }
+
+func TestHookHandle(t *testing.T) {
+	ww := NewHookWriter(ioutil.Discard)
+	count := 0
+	handle := ww.AddHookFunc(func(data []byte) bool {
+		count++
+		return true
+	})
+	_, _ = io.WriteString(ww, "test remove")
+	handle.Remove()
+	handle.Remove()
+	_, _ = io.WriteString(ww, "test remove")
+
+	if count != 1 {
+		t.Error("remove hook", count)
+	}
+}
+
+func TestAddHook(t *testing.T) {
+	l := NewWithOptions(Options{Level: zapcore.InfoLevel, Caller: true, DisableIdentity: true})
+	l.SetHookFunc(func(data []byte) bool {
+		return false
+	})
+
+	entries := make([]*Entry, 0)
+	handle := l.AddHook(zapcore.WarnLevel, func(entry *Entry) {
+		entries = append(entries, entry)
+	})
+	//hook的panic不影响写日志
+	l.AddHook(zapcore.ErrorLevel, func(entry *Entry) {
+		panic("hook panic")
+	})
+
+	child := l.Named("child").With("request_id", "abc")
+	child.Info("test hook info")
+	child.Warnw("test hook warn", "k1", 1)
+	child.Error("test hook error")
+	handle.Remove()
+	child.Error("test hook removed")
+
+	if len(entries) != 2 {
+		t.Fatal("entries", len(entries))
+	}
+	e := entries[0]
+	if e.Level != zapcore.WarnLevel || e.Message != "test hook warn" || e.LoggerName != "child" {
+		t.Error("entry", e)
+	}
+	if e.Fields["request_id"] != "abc" || e.Fields["k1"] != int64(1) {
+		t.Error("entry fields", e.Fields)
+	}
+	if !strings.HasPrefix(e.Caller, "logger/hook_test.go") {
+		t.Error("entry caller", e.Caller)
+	}
+}
+
+func TestHookLevelBelowLogger(t *testing.T) {
+	l := New("/dev/stdout", zapcore.ErrorLevel)
+	count := 0
+	l.AddHook(zapcore.DebugLevel, func(entry *Entry) {
+		count++
+	})
+	l.Debug("test hook debug")
+	if count != 1 {
+		t.Error("hook below logger level", count)
+	}
+}
+
+func TestHookConcurrent(t *testing.T) {
+	l := NewWithOptions(Options{Path: "/dev/stdout", Level: zapcore.InfoLevel})
+	l.SetHookFunc(func(data []byte) bool {
+		return false
+	})
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Info("test concurrent")
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		l.SetHookFunc(func(data []byte) bool { return false }).Remove()
+		l.AddHook(zapcore.InfoLevel, func(entry *Entry) {}).Remove()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,55 @@
+package logger
+
+/**
+ * 运行时修改日志等级
+ *
+ * 通过SetLevel直接修改
+ * 通过LevelHandler提供的http接口修改：GET获取等级，PUT修改等级，如：curl -X PUT -d '{"level":"debug"}'
+ * 通过BindLevel绑定配置中心的key，配置变化时修改
+ */
+
+import (
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"strings"
+)
+
+//配置监控接口，*conf.Conf实现了此接口
+type LevelWatcher interface {
+	Watch(namespace string, key string, handler func(oldCfg string, newCfg string))
+}
+
+//修改日志等级
+func (l *Logger) SetLevel(level zapcore.Level) {
+	l.level.SetLevel(level)
+}
+
+//获取日志等级
+func (l *Logger) GetLevel() zapcore.Level {
+	return l.level.Level()
+}
+
+//获取修改日志等级的http接口，GET获取等级，PUT修改等级
+func (l *Logger) LevelHandler() http.Handler {
+	return l.level
+}
+
+//绑定配置中心的key，key的值为日志等级，如：debug、info
+//key被删除时恢复为绑定时的日志等级，key的值无法解析时保持当前等级
+func (l *Logger) BindLevel(watcher LevelWatcher, namespace string, key string) {
+	initLevel := l.GetLevel()
+	watcher.Watch(namespace, key, func(oldCfg string, newCfg string) {
+		newCfg = strings.TrimSpace(newCfg)
+		if newCfg == "" {
+			l.SetLevel(initLevel)
+			return
+		}
+
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(newCfg)); err != nil {
+			l.Warnw("invalid log level", "namespace", namespace, "key", key, "value", newCfg)
+			return
+		}
+		l.SetLevel(level)
+	})
+}
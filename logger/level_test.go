@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"go.uber.org/zap/zapcore"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type tLevelWatcher struct {
+	handler func(string, string)
+}
+
+func (w *tLevelWatcher) Watch(namespace string, key string, handler func(oldCfg string, newCfg string)) {
+	w.handler = handler
+}
+
+func TestSetLevel(t *testing.T) {
+	l := New("/dev/stdout", zapcore.InfoLevel)
+	count := 0
+	l.SetHookFunc(func(data []byte) bool {
+		count++
+		return false
+	})
+
+	l.Debug("test set level")
+	l.SetLevel(zapcore.DebugLevel)
+	l.Debug("test set level")
+
+	if count != 1 || l.GetLevel() != zapcore.DebugLevel {
+		t.Error("set level", count, l.GetLevel())
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	l := New("/dev/stdout", zapcore.InfoLevel)
+	handler := l.LevelHandler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("PUT", "/log/level", strings.NewReader(`{"level":"warn"}`)))
+	if l.GetLevel() != zapcore.WarnLevel {
+		t.Error("put level", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/log/level", nil))
+	if !strings.Contains(w.Body.String(), "warn") {
+		t.Error("get level", w.Body.String())
+	}
+}
+
+func TestBindLevel(t *testing.T) {
+	l := New("/dev/stdout", zapcore.InfoLevel)
+	watcher := &tLevelWatcher{}
+	l.BindLevel(watcher, "application", "log_level")
+
+	watcher.handler("", "debug")
+	if l.GetLevel() != zapcore.DebugLevel {
+		t.Error("bind debug", l.GetLevel())
+	}
+	watcher.handler("debug", "xxx")
+	if l.GetLevel() != zapcore.DebugLevel {
+		t.Error("bind invalid", l.GetLevel())
+	}
+	watcher.handler("debug", "")
+	if l.GetLevel() != zapcore.InfoLevel {
+		t.Error("bind delete", l.GetLevel())
+	}
+}
@@ -1,56 +1,281 @@
 package logger
 
 import (
-	"github.com/natefinch/lumberjack"
+	"github.com/vrg0/go-common/args"
+	"github.com/vrg0/go-common/util"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"io"
 	"log"
 	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+//日志格式
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+//json格式中固定的字段名
+const (
+	TimeKey       = "time"
+	LevelKey      = "level"
+	NameKey       = "logger"
+	CallerKey     = "caller"
+	MessageKey    = "msg"
+	StacktraceKey = "stacktrace"
+)
+
+var (
+	localIp     = ""
+	localIpOnce = new(sync.Once)
 )
 
 type Logger struct {
-	sugar  *zap.SugaredLogger
-	logger *zap.Logger
-	writer *hookWriter
+	sugar      *zap.SugaredLogger
+	logger     *zap.Logger
+	writer     *hookWriter
+	level      zap.AtomicLevel
+	rotators   []*rotateWriter
+	asyncs     []*asyncWriter
+	hooks      *entryHooks
+	suppressor *suppressor
+	sighup     *sighupListener //没有开启ReopenOnSIGHUP时为nil
+}
+
+type Options struct {
+	Path            string           //日志路径，为空时为/dev/stdout
+	Level           zapcore.Level    //日志等级
+	Format          string           //日志格式，console | json，为空时读取args中的log_format，默认为console
+	Caller          bool             //是否打印调用位置
+	Stacktrace      bool             //是否打印调用栈
+	StacktraceLevel zapcore.Level    //打印调用栈的最低等级，Stacktrace为true时有效
+	DisableIdentity bool             //不打印app_name、idc、ip、pid字段
+	Rotate          RotateOptions    //日志文件切割策略
+	Sinks           []Sink           //额外的日志输出，每个输出有独立的等级和格式
+	Async           *AsyncOptions    //异步写日志，为nil时同步写日志
+	Sampling        *SamplingOptions //日志采样，为nil时不采样
+	SummaryInterval time.Duration    //输出被采样和限流丢弃的日志汇总的间隔，为0时为1分钟
+	Redact          *RedactOptions   //日志脱敏，为nil时不脱敏
+}
+
+//额外的日志输出，如：warn及以上等级输出到stderr，error及以上等级输出到单独的文件
+//hook只对Options.Path对应的主输出有效，SetLevel只修改主输出的等级
+type Sink struct {
+	Path   string        //日志路径，/dev/stdout | /dev/stderr | 文件路径
+	Level  zapcore.Level //日志等级
+	Format string        //日志格式，为空时与Options.Format相同
+	Rotate RotateOptions //日志文件切割策略，为空时与Options.Rotate相同
 }
 
 // 新建Logger对象，成功返回对象指针，失败返回nil
 func New(logPath string, level zapcore.Level) *Logger {
+	return NewWithOptions(Options{Path: logPath, Level: level})
+}
+
+// 根据Options新建Logger对象，脱敏的正则表达式不合法时忽略该表达式，并写入一条error日志
+func NewWithOptions(opts Options) *Logger {
+	logPath := opts.Path
 	//参数过滤
 	if logPath == "" {
 		logPath = "/dev/stdout"
 	}
 
-	rtn := Logger{}
+	var r *redactor
+	redactErrs := make([]error, 0)
+	if opts.Redact != nil {
+		r, redactErrs = newRedactor(*opts.Redact)
+	}
 
+	rtn := Logger{
+		level: zap.NewAtomicLevelAt(opts.Level),
+		hooks: newEntryHooks(),
+	}
+	rtn.suppressor = newSuppressor(opts.SummaryInterval, rtn.reportSuppressed)
+	rtn.suppressor.redactor = r
+
+	//主输出
+	rtn.writer = NewHookWriter(rtn.newWriter(logPath, opts.Rotate, opts.Async))
+	cores := []zapcore.Core{
+		zapcore.NewCore(newEncoder(opts.Format), zapcore.AddSync(rtn.writer), rtn.level),
+	}
+
+	//额外的输出
+	reopenOnSIGHUP := opts.Rotate.ReopenOnSIGHUP
+	for _, sink := range opts.Sinks {
+		format := sink.Format
+		if format == "" {
+			format = opts.Format
+		}
+		rotate := sink.Rotate
+		if rotate == (RotateOptions{}) {
+			rotate = opts.Rotate
+		}
+		reopenOnSIGHUP = reopenOnSIGHUP || rotate.ReopenOnSIGHUP
+		writer := rtn.newWriter(sink.Path, rotate, opts.Async)
+		cores = append(cores, zapcore.NewCore(newEncoder(format), zapcore.AddSync(writer), sink.Level))
+	}
+
+	zapOpts := make([]zap.Option, 0)
+	if opts.Caller {
+		zapOpts = append(zapOpts, zap.AddCaller())
+	}
+	if opts.Stacktrace {
+		zapOpts = append(zapOpts, zap.AddStacktrace(opts.StacktraceLevel))
+	}
+	if !opts.DisableIdentity {
+		zapOpts = append(zapOpts, zap.Fields(identityFields()...))
+	}
+	core := newHookCore(zapcore.NewTee(cores...), rtn.hooks)
+	if r != nil {
+		core = newRedactCore(core, r)
+	}
+	if opts.Sampling != nil {
+		core = newSampleCore(core, *opts.Sampling, rtn.suppressor)
+	}
+	rtn.setLogger(zap.New(core, zapOpts...))
+
+	if reopenOnSIGHUP && len(rtn.rotators) != 0 {
+		rtn.reopenOnSIGHUP()
+	}
+
+	for _, err := range redactErrs {
+		rtn.logger.Error("redact pattern ignored", zap.Error(err))
+	}
+
+	return &rtn
+}
+
+//根据日志路径新建writer，写入文件时按RotateOptions切割，async不为nil时异步写入
+func (l *Logger) newWriter(logPath string, rotate RotateOptions, async *AsyncOptions) io.Writer {
 	var writer io.Writer
 	switch logPath {
-	case "/dev/stdout":
+	case "", "/dev/stdout":
 		writer = os.Stdout
 	case "/dev/stderr":
 		writer = os.Stderr
 	default:
-		writer = &lumberjack.Logger{
-			Filename:   logPath, //日志路径
-			MaxSize:    1024,    //日志大小，单位MB
-			MaxBackups: 30,      //日志文件最多保存备份
-			MaxAge:     7,       //日志文件最多保存多少天
-			LocalTime:  true,    //打印本地时间
-			Compress:   false,   //日志备份不进行压缩，压缩会导致占用过多cpu
-		}
+		rw := newRotateWriter(logPath, rotate)
+		l.rotators = append(l.rotators, rw)
+		writer = rw
+	}
+
+	if async != nil {
+		aw := newAsyncWriter(writer, *async)
+		l.asyncs = append(l.asyncs, aw)
+		writer = aw
+	}
+	return writer
+}
+
+func (l *Logger) setLogger(logger *zap.Logger) {
+	l.logger = logger
+	//跳过Logger的封装方法，使caller指向调用方
+	l.sugar = logger.WithOptions(zap.AddCallerSkip(1)).Sugar()
+}
+
+//派生出新的Logger，共享writer和日志等级
+func (l *Logger) derive(logger *zap.Logger) *Logger {
+	rtn := &Logger{
+		writer:     l.writer,
+		level:      l.level,
+		rotators:   l.rotators,
+		asyncs:     l.asyncs,
+		hooks:      l.hooks,
+		suppressor: l.suppressor,
+		sighup:     l.sighup,
+	}
+	rtn.setLogger(logger)
+	return rtn
+}
+
+//派生出带有字段的Logger，如：l.With("request_id", id)
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	return l.derive(l.logger.Sugar().With(keysAndValues...).Desugar())
+}
+
+//派生出带有名称的Logger，多次调用时名称用"."连接
+func (l *Logger) Named(name string) *Logger {
+	return l.derive(l.logger.Named(name))
+}
+
+//服务标识字段：app_name、idc、ip、pid，app_name和idc读取自args，不存在时不打印
+func identityFields() []zap.Field {
+	rtn := make([]zap.Field, 0, 4)
+	if appName, ok := args.Get("app_name"); ok {
+		rtn = append(rtn, zap.String("app_name", appName))
+	}
+	if idc, ok := args.Get("idc"); ok {
+		rtn = append(rtn, zap.String("idc", idc))
+	}
+	localIpOnce.Do(func() {
+		localIp, _ = util.LocalIp()
+	})
+	if localIp != "" {
+		rtn = append(rtn, zap.String("ip", localIp))
+	}
+	rtn = append(rtn, zap.Int("pid", os.Getpid()))
+	return rtn
+}
+
+//根据日志格式新建encoder，format为空时读取args中的log_format
+func newEncoder(format string) zapcore.Encoder {
+	if format == "" {
+		format = args.GetOrDefault("log_format", FormatConsole)
 	}
-	rtn.writer = NewHookWriter(writer)
 
 	config := zap.NewProductionEncoderConfig()
+	config.TimeKey = TimeKey
+	config.LevelKey = LevelKey
+	config.NameKey = NameKey
+	config.CallerKey = CallerKey
+	config.MessageKey = MessageKey
+	config.StacktraceKey = StacktraceKey
 	config.EncodeTime = zapcore.ISO8601TimeEncoder
-	encoder := zapcore.NewConsoleEncoder(config)
-	writeSyncer := zapcore.AddSync(rtn.writer)
-	logger := zap.New(zapcore.NewCore(encoder, writeSyncer, level))
-	rtn.logger = logger
-	rtn.sugar = logger.Sugar()
 
-	return &rtn
+	if strings.ToLower(format) == FormatJSON {
+		return zapcore.NewJSONEncoder(config)
+	}
+	return zapcore.NewConsoleEncoder(config)
+}
+
+//写入缓存中的日志，异步写日志时会等待队列中的日志全部写入
+func (l *Logger) Sync() error {
+	return l.logger.Sync()
+}
+
+//写入剩余的日志并关闭日志文件，关闭后不能再写日志
+func (l *Logger) Close() error {
+	var rtn error
+	//先停止监听SIGHUP，避免重新打开已关闭的文件
+	if l.sighup != nil {
+		l.sighup.stop()
+	}
+	//再输出被丢弃日志的汇总
+	l.suppressor.close()
+	for _, aw := range l.asyncs {
+		if err := aw.Close(); err != nil && rtn == nil {
+			rtn = err
+		}
+	}
+	for _, rw := range l.rotators {
+		if err := rw.logger.Close(); err != nil && rtn == nil {
+			rtn = err
+		}
+	}
+	return rtn
+}
+
+//异步写日志时，因队列满而丢弃的日志条数
+func (l *Logger) Dropped() uint64 {
+	rtn := uint64(0)
+	for _, aw := range l.asyncs {
+		rtn += aw.Dropped()
+	}
+	return rtn
 }
 
 func (l *Logger) GetStandardLogger() *log.Logger {
@@ -61,8 +286,15 @@ func (l *Logger) GetSugaredLogger() *zap.SugaredLogger {
 	return l.logger.Sugar()
 }
 
-func (l *Logger) SetHookFunc(hookFunc HookFunc) {
-	l.writer.AddHookFunc(hookFunc)
+//添加编码后日志的hook，只对主输出有效，返回的句柄用于注销hook
+func (l *Logger) SetHookFunc(hookFunc HookFunc) *HookHandle {
+	return l.writer.AddHookFunc(hookFunc)
+}
+
+//添加结构化日志的hook，等级大于等于level的日志会调用hook，返回的句柄用于注销hook
+//hook在写日志的协程中同步调用，耗时的操作需要hook自行异步处理
+func (l *Logger) AddHook(level zapcore.Level, hook EntryHookFunc) *HookHandle {
+	return l.hooks.add(level, hook)
 }
 
 func (l *Logger) Debug(args ...interface{}) {
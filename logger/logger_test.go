@@ -2,11 +2,13 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
 	"github.com/vrg0/go-common/args"
 	"go.uber.org/zap/zapcore"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -207,3 +209,180 @@ func TestGetStandardLogger(t *testing.T) {
 	})
 	sl.Print("test get standard logger")
 }
+
+func TestJSONFormat(t *testing.T) {
+	l := NewWithOptions(Options{Path: "/dev/stdout", Level: zapcore.DebugLevel, Format: FormatJSON})
+	var line []byte
+	l.SetHookFunc(func(data []byte) bool {
+		line = append([]byte(nil), data...)
+		return false
+	})
+
+	l.Infow("test json", "k1", "v1")
+
+	entry := make(map[string]interface{})
+	if err := json.Unmarshal(line, &entry); err != nil {
+		t.Fatal(err, string(line))
+	}
+	for _, key := range []string{TimeKey, LevelKey, MessageKey, "k1"} {
+		if _, ok := entry[key]; !ok {
+			t.Error("json without", key, string(line))
+		}
+	}
+	if entry[MessageKey] != "test json" || entry[LevelKey] != "info" {
+		t.Error(string(line))
+	}
+}
+
+func TestFormatFromArgs(t *testing.T) {
+	restore := args.Override("log_format", FormatJSON)
+	defer restore()
+
+	l := New("/dev/stdout", zapcore.DebugLevel)
+	l.SetHookFunc(func(data []byte) bool {
+		if !json.Valid(data) {
+			t.Error("not json", string(data))
+		}
+		return false
+	})
+	l.Info("test format from args")
+}
+
+func TestCallerAndIdentity(t *testing.T) {
+	restore := args.Override("app_name", "logger_test")
+	defer restore()
+
+	l := NewWithOptions(Options{
+		Level:           zapcore.DebugLevel,
+		Format:          FormatJSON,
+		Caller:          true,
+		Stacktrace:      true,
+		StacktraceLevel: zapcore.ErrorLevel,
+	})
+	entries := make([]map[string]interface{}, 0)
+	l.SetHookFunc(func(data []byte) bool {
+		entry := make(map[string]interface{})
+		if err := json.Unmarshal(data, &entry); err != nil {
+			t.Error(err)
+		}
+		entries = append(entries, entry)
+		return false
+	})
+
+	l.Info("test caller")
+	l.Error("test stacktrace")
+
+	if len(entries) != 2 {
+		t.Fatal("entries", entries)
+	}
+	if caller, _ := entries[0][CallerKey].(string); !strings.HasPrefix(caller, "logger/logger_test.go") {
+		t.Error("caller", entries[0][CallerKey])
+	}
+	if _, ok := entries[0][StacktraceKey]; ok {
+		t.Error("stacktrace at info")
+	}
+	if _, ok := entries[1][StacktraceKey]; !ok {
+		t.Error("stacktrace at error")
+	}
+	if entries[0]["app_name"] != "logger_test" || entries[0]["pid"] != float64(os.Getpid()) {
+		t.Error("identity", entries[0])
+	}
+
+	l = NewWithOptions(Options{Format: FormatJSON, DisableIdentity: true})
+	l.SetHookFunc(func(data []byte) bool {
+		if strings.Contains(string(data), `"pid"`) {
+			t.Error("disable identity", string(data))
+		}
+		return false
+	})
+	l.Info("test disable identity")
+}
+
+func TestWithNamed(t *testing.T) {
+	l := NewWithOptions(Options{Level: zapcore.DebugLevel, Format: FormatJSON, DisableIdentity: true})
+	var line []byte
+	l.SetHookFunc(func(data []byte) bool {
+		line = append([]byte(nil), data...)
+		return false
+	})
+
+	child := l.Named("http").Named("api").With("request_id", "abc")
+	child.Infow("test with", "k1", "v1")
+
+	entry := make(map[string]interface{})
+	if err := json.Unmarshal(line, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry[NameKey] != "http.api" || entry["request_id"] != "abc" || entry["k1"] != "v1" {
+		t.Error("with named", string(line))
+	}
+
+	//子Logger共享日志等级，父Logger不受字段影响
+	l.SetLevel(zapcore.WarnLevel)
+	line = nil
+	child.Info("test level")
+	if line != nil {
+		t.Error("child level", string(line))
+	}
+	l.Warn("test parent")
+	if strings.Contains(string(line), "request_id") {
+		t.Error("parent with fields", string(line))
+	}
+}
+
+func TestSinks(t *testing.T) {
+	dir := tempLogDir(t)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	allPath := filepath.Join(dir, "all.log")
+	errPath := filepath.Join(dir, "error.log")
+	l := NewWithOptions(Options{
+		Path:  allPath,
+		Level: zapcore.DebugLevel,
+		Sinks: []Sink{
+			{Path: "/dev/stderr", Level: zapcore.WarnLevel},
+			{Path: errPath, Level: zapcore.ErrorLevel, Format: FormatJSON},
+		},
+	})
+	l.Debug("test sinks debug")
+	l.Warn("test sinks warn")
+	l.Error("test sinks error")
+
+	all, _ := ioutil.ReadFile(allPath)
+	errData, _ := ioutil.ReadFile(errPath)
+	if n := bytes.Count(all, []byte("\n")); n != 3 {
+		t.Error("all.log lines", n)
+	}
+	if n := bytes.Count(errData, []byte("\n")); n != 1 || !bytes.Contains(errData, []byte(`"msg":"test sinks error"`)) {
+		t.Error("error.log", string(errData))
+	}
+}
+
+func TestSyncStdout(t *testing.T) {
+	//stdout为管道时Sync返回EINVAL，Logger.Sync需要忽略
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() {
+		os.Stdout = stdout
+		_ = w.Close()
+	}()
+	go func() {
+		_, _ = ioutil.ReadAll(r)
+	}()
+
+	for _, async := range []*AsyncOptions{nil, {}} {
+		l := NewWithOptions(Options{Path: "/dev/stdout", Level: zapcore.InfoLevel, Async: async})
+		l.Info("test sync stdout")
+		if err := l.Sync(); err != nil {
+			t.Error("sync", async != nil, err)
+		}
+		_ = l.Close()
+	}
+}
@@ -0,0 +1,184 @@
+package logger
+
+/**
+ * 把日志转发到notify(钉钉等)
+ *
+ * 等级大于等于Level的日志会转发到notify，相同的日志在一个窗口期内只发送第一条，
+ * 窗口期结束时发送汇总，如："[x 37 in last 5m0s] ..."
+ * 日志先写入有界队列，由后台协程发送，队列满时丢弃，不会阻塞写日志
+ * 发送使用Notify.SendText，遵循notify的ignore、正则和限流规则
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/vrg0/go-common/notify"
+	"go.uber.org/zap/zapcore"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultNotifyWindow    = time.Minute * 5
+	defaultNotifyQueueSize = 1024
+)
+
+type NotifyOptions struct {
+	Level     zapcore.Level //转发的最低等级
+	Window    time.Duration //聚合相同日志的窗口期，默认为5分钟
+	QueueSize int           //待发送队列的长度，默认为1024
+}
+
+type notifyAggregate struct {
+	entry *Entry
+	count int //窗口期内被聚合(未发送)的条数
+}
+
+type notifyHook struct {
+	send    func(body string)
+	window  time.Duration
+	queue   chan *Entry
+	stop    chan struct{}
+	done    chan struct{}
+	dropped uint64
+}
+
+//添加转发到notify的hook，返回的句柄用于注销hook，注销时会发送未发送的汇总
+//n为nil时不添加hook，返回的句柄可以正常调用Remove
+func (l *Logger) AddNotifyHook(n *notify.Notify, opts NotifyOptions) *HookHandle {
+	if n == nil {
+		return newHookHandle(func() {})
+	}
+	nh := newNotifyHook(n.SendText, opts)
+	handle := l.AddHook(opts.Level, nh.hook)
+	return newHookHandle(func() {
+		handle.Remove()
+		nh.close()
+	})
+}
+
+func newNotifyHook(send func(body string), opts NotifyOptions) *notifyHook {
+	if opts.Window <= 0 {
+		opts.Window = defaultNotifyWindow
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultNotifyQueueSize
+	}
+
+	nh := &notifyHook{
+		send:   send,
+		window: opts.Window,
+		queue:  make(chan *Entry, opts.QueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go nh.run()
+	return nh
+}
+
+//在写日志的协程中调用，队列满时丢弃
+func (nh *notifyHook) hook(entry *Entry) {
+	e := *entry
+	select {
+	case nh.queue <- &e:
+	default:
+		atomic.AddUint64(&nh.dropped, 1)
+	}
+}
+
+func (nh *notifyHook) run() {
+	defer close(nh.done)
+
+	ticker := time.NewTicker(nh.window)
+	defer ticker.Stop()
+
+	aggregates := make(map[string]*notifyAggregate)
+	flush := func() {
+		for _, agg := range aggregates {
+			if agg.count > 0 {
+				nh.send(fmt.Sprintf("[x %d in last %s] %s", agg.count, nh.window, formatNotify(agg.entry)))
+			}
+		}
+		aggregates = make(map[string]*notifyAggregate)
+		if dropped := atomic.SwapUint64(&nh.dropped, 0); dropped > 0 {
+			nh.send(fmt.Sprintf("[logger] %d entries dropped by notify hook, queue full", dropped))
+		}
+	}
+
+	add := func(entry *Entry) {
+		key := notifyKey(entry)
+		if agg, ok := aggregates[key]; ok {
+			agg.count++
+			return
+		}
+		aggregates[key] = &notifyAggregate{entry: entry}
+		nh.send(formatNotify(entry))
+	}
+
+	for {
+		select {
+		case entry := <-nh.queue:
+			add(entry)
+		case <-ticker.C:
+			flush()
+		case <-nh.stop:
+			//发送队列中剩余的日志和汇总
+			for {
+				select {
+				case entry := <-nh.queue:
+					add(entry)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (nh *notifyHook) close() {
+	close(nh.stop)
+	<-nh.done
+}
+
+//等级、名称、调用位置和内容相同的日志视为相同
+func notifyKey(entry *Entry) string {
+	return strings.Join([]string{entry.Level.String(), entry.LoggerName, entry.Caller, entry.Message}, "\x00")
+}
+
+func formatNotify(entry *Entry) string {
+	sb := new(strings.Builder)
+	sb.WriteString(entry.Time.Format("2006-01-02T15:04:05.000Z0700"))
+	sb.WriteString(" ")
+	sb.WriteString(entry.Level.CapitalString())
+	for _, s := range []string{entry.LoggerName, entry.Caller} {
+		if s != "" {
+			sb.WriteString(" ")
+			sb.WriteString(s)
+		}
+	}
+	sb.WriteString("\n")
+	sb.WriteString(entry.Message)
+
+	if len(entry.Fields) > 0 {
+		keys := make([]string, 0, len(entry.Fields))
+		for k := range entry.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			value, err := json.Marshal(entry.Fields[k])
+			if err != nil {
+				value = []byte(fmt.Sprint(entry.Fields[k]))
+			}
+			sb.WriteString(fmt.Sprintf("\n%s=%s", k, value))
+		}
+	}
+	if entry.Stack != "" {
+		sb.WriteString("\n")
+		sb.WriteString(entry.Stack)
+	}
+	return sb.String()
+}
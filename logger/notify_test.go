@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"encoding/json"
+	"github.com/vrg0/go-common/notify"
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNotifyHook(t *testing.T) {
+	lock := new(sync.Mutex)
+	sent := make([]string, 0)
+	nh := newNotifyHook(func(body string) {
+		lock.Lock()
+		sent = append(sent, body)
+		lock.Unlock()
+	}, NotifyOptions{Window: time.Hour})
+
+	for i := 0; i < 37; i++ {
+		nh.hook(&Entry{Level: zapcore.ErrorLevel, Message: "test notify same"})
+	}
+	nh.hook(&Entry{Level: zapcore.ErrorLevel, Message: "test notify other", Fields: map[string]interface{}{"k1": 1}})
+	nh.close()
+
+	if len(sent) != 3 {
+		t.Fatal("sent", sent)
+	}
+	if !strings.HasSuffix(sent[0], "ERROR\ntest notify same") {
+		t.Error("first", sent[0])
+	}
+	if !strings.HasSuffix(sent[1], "test notify other\nk1=1") {
+		t.Error("other", sent[1])
+	}
+	if !strings.HasPrefix(sent[2], "[x 36 in last 1h0m0s] ") {
+		t.Error("summary", sent[2])
+	}
+}
+
+func TestAddNotifyHook(t *testing.T) {
+	lock := new(sync.Mutex)
+	sent := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := notify.Message{}
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		lock.Lock()
+		sent = append(sent, msg.Text.Content)
+		lock.Unlock()
+	}))
+	defer server.Close()
+
+	n := notify.New([]string{server.URL})
+	n.SetIgnore([]string{"ignored"})
+
+	l := NewWithOptions(Options{Path: "/dev/stdout", Level: zapcore.InfoLevel, Caller: true})
+	handle := l.AddNotifyHook(n, NotifyOptions{Level: zapcore.ErrorLevel})
+	l.Warn("test notify warn")
+	l.Error("test notify error")
+	l.Error("test notify ignored")
+	handle.Remove()
+	l.Error("test notify removed")
+
+	if len(sent) != 1 || !strings.Contains(sent[0], "test notify error") {
+		t.Error("sent", sent)
+	}
+}
+
+func TestAddNilNotifyHook(t *testing.T) {
+	l := NewWithOptions(Options{Path: "/dev/stdout", Level: zapcore.InfoLevel})
+	handle := l.AddNotifyHook(nil, NotifyOptions{Level: zapcore.ErrorLevel})
+	l.Error("test notify nil")
+	handle.Remove()
+}
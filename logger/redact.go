@@ -0,0 +1,187 @@
+package logger
+
+/**
+ * 日志脱敏
+ *
+ * 在编码之前替换敏感信息，对所有输出和hook有效：
+ * 字段名在Fields中(不区分大小写)时，整个字段的值替换为Mask
+ * 字符串类型的字段值和日志内容中匹配Patterns的部分替换为Mask
+ * map、结构体、zapcore.ObjectMarshaler和zapcore.ArrayMarshaler类型的字段会展开后逐层脱敏
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"regexp"
+	"strings"
+)
+
+const defaultRedactMask = "******"
+
+//日志脱敏配置
+type RedactOptions struct {
+	Fields   []string //需要脱敏的字段名，如：password、token
+	Patterns []string //需要脱敏的正则表达式，如：手机号 1[3-9]\d{9}
+	Mask     string   //替换敏感信息的字符串，为空时为"******"
+}
+
+type redactor struct {
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+	mask     string
+}
+
+//不合法的正则表达式会被忽略，并返回对应的错误
+func newRedactor(opts RedactOptions) (*redactor, []error) {
+	rtn := &redactor{
+		fields:   make(map[string]bool),
+		patterns: make([]*regexp.Regexp, 0, len(opts.Patterns)),
+		mask:     opts.Mask,
+	}
+	if rtn.mask == "" {
+		rtn.mask = defaultRedactMask
+	}
+	for _, field := range opts.Fields {
+		rtn.fields[strings.ToLower(field)] = true
+	}
+	errs := make([]error, 0)
+	for _, pattern := range opts.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid redact pattern %q: %s", pattern, err.Error()))
+			continue
+		}
+		rtn.patterns = append(rtn.patterns, re)
+	}
+	return rtn, errs
+}
+
+//替换字符串中匹配的部分
+func (r *redactor) redactString(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllLiteralString(s, r.mask)
+	}
+	return s
+}
+
+func (r *redactor) redactField(f zapcore.Field) zapcore.Field {
+	if r.fields[strings.ToLower(f.Key)] {
+		return zap.String(f.Key, r.mask)
+	}
+	if len(r.fields) == 0 && len(r.patterns) == 0 {
+		return f
+	}
+
+	switch f.Type {
+	case zapcore.ReflectType:
+		if v, ok := toPlain(f.Interface); ok {
+			return zap.Any(f.Key, r.redactValue(v))
+		}
+	case zapcore.ObjectMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		if err := f.Interface.(zapcore.ObjectMarshaler).MarshalLogObject(enc); err == nil {
+			return zap.Any(f.Key, r.redactValue(enc.Fields))
+		}
+	case zapcore.ArrayMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		if err := enc.AddArray(f.Key, f.Interface.(zapcore.ArrayMarshaler)); err == nil {
+			return zap.Any(f.Key, r.redactValue(enc.Fields[f.Key]))
+		}
+	case zapcore.StringType:
+		f.String = r.redactString(f.String)
+	case zapcore.ByteStringType:
+		return zap.ByteString(f.Key, []byte(r.redactString(string(f.Interface.([]byte)))))
+	case zapcore.StringerType:
+		return zap.String(f.Key, r.redactString(f.Interface.(fmt.Stringer).String()))
+	case zapcore.ErrorType:
+		return zap.String(f.Key, r.redactString(f.Interface.(error).Error()))
+	}
+	return f
+}
+
+//逐层脱敏map和数组中的值，key在Fields中时整个值替换为Mask
+func (r *redactor) redactValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		rtn := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			if r.fields[strings.ToLower(k)] {
+				rtn[k] = r.mask
+			} else {
+				rtn[k] = r.redactValue(item)
+			}
+		}
+		return rtn
+	case []interface{}:
+		rtn := make([]interface{}, len(value))
+		for i, item := range value {
+			rtn[i] = r.redactValue(item)
+		}
+		return rtn
+	case string:
+		return r.redactString(value)
+	default:
+		return v
+	}
+}
+
+//通过json转换为map[string]interface{}、[]interface{}、string等基本类型，数字保留原样
+func toPlain(v interface{}) (interface{}, bool) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var rtn interface{}
+	if err := decoder.Decode(&rtn); err != nil {
+		return nil, false
+	}
+	return rtn, true
+}
+
+func (r *redactor) redactFields(fields []zapcore.Field) []zapcore.Field {
+	rtn := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		rtn[i] = r.redactField(f)
+	}
+	return rtn
+}
+
+//在写入内层的core之前脱敏
+type redactCore struct {
+	zapcore.Core
+	redactor *redactor
+}
+
+func newRedactCore(core zapcore.Core, r *redactor) zapcore.Core {
+	return &redactCore{
+		Core:     core,
+		redactor: r,
+	}
+}
+
+func (rc *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{
+		Core:     rc.Core.With(rc.redactor.redactFields(fields)),
+		redactor: rc.redactor,
+	}
+}
+
+func (rc *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if rc.Enabled(ent.Level) {
+		return ce.AddCore(ent, rc)
+	}
+	return ce
+}
+
+func (rc *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = rc.redactor.redactString(ent.Message)
+	if ce := rc.Core.Check(ent, nil); ce != nil {
+		ce.Write(rc.redactor.redactFields(fields)...)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"errors"
+	"go.uber.org/zap/zapcore"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedact(t *testing.T) {
+	opts := RedactOptions{
+		Fields:   []string{"password", "Token"},
+		Patterns: []string{`1[3-9]\d{9}`},
+	}
+
+	for _, format := range []string{FormatConsole, FormatJSON} {
+		logPath := filepath.Join(tempLogDir(t), "redact.log")
+		l := NewWithOptions(Options{Path: logPath, Level: zapcore.InfoLevel, Format: format, Redact: &opts})
+
+		var hooked *Entry
+		l.AddHook(zapcore.InfoLevel, func(entry *Entry) {
+			hooked = entry
+		})
+
+		l.With("token", "secret-token").Infow("test redact phone 13812345678",
+			"PASSWORD", "secret-password",
+			"mobile", "13912345678",
+			"err", errors.New("call 13712345678 failed"),
+			"count", 13612345678,
+		)
+		l.Infof("test redact %s", "13512345678")
+		_ = l.Close()
+
+		data, err := ioutil.ReadFile(logPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := string(data)
+		for _, secret := range []string{"secret-token", "secret-password", "13812345678", "13912345678", "13712345678", "13512345678"} {
+			if strings.Contains(content, secret) {
+				t.Error(format, "not redacted", secret, content)
+			}
+		}
+		//非字符串类型的字段不匹配正则表达式
+		if !strings.Contains(content, "13612345678") || !strings.Contains(content, "test redact phone ******") {
+			t.Error(format, content)
+		}
+
+		if hooked == nil || hooked.Message != "test redact ******" {
+			t.Fatal(format, "hook", hooked)
+		}
+	}
+}
+
+func TestRedactInvalidPattern(t *testing.T) {
+	logPath := filepath.Join(tempLogDir(t), "redact.log")
+	l := NewWithOptions(Options{
+		Path:            logPath,
+		Level:           zapcore.InfoLevel,
+		Format:          FormatJSON,
+		DisableIdentity: true,
+		Redact:          &RedactOptions{Patterns: []string{"(", `1[3-9]\d{9}`}},
+	})
+	if l == nil {
+		t.Fatal("nil logger")
+	}
+	l.Info("test redact 13812345678")
+	_ = l.Close()
+
+	lines := readJSONLines(t, logPath)
+	if len(lines) != 2 {
+		t.Fatal("lines", lines)
+	}
+	if lines[0]["msg"] != "redact pattern ignored" || !strings.Contains(lines[0]["error"].(string), `"("`) {
+		t.Error("invalid pattern", lines[0])
+	}
+	if lines[1]["msg"] != "test redact ******" {
+		t.Error("valid pattern", lines[1])
+	}
+}
+
+type tRedactObject struct{}
+
+func (tRedactObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("token", "secret-object-token")
+	enc.AddString("mobile", "13812345678")
+	return enc.AddArray("list", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		enc.AppendString("13912345678")
+		return nil
+	}))
+}
+
+func TestRedactNested(t *testing.T) {
+	logPath := filepath.Join(tempLogDir(t), "redact.log")
+	l := NewWithOptions(Options{
+		Path:            logPath,
+		Level:           zapcore.InfoLevel,
+		Format:          FormatJSON,
+		DisableIdentity: true,
+		Redact:          &RedactOptions{Fields: []string{"password", "token"}, Patterns: []string{`1[3-9]\d{9}`}},
+	})
+
+	type account struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+		Mobiles  []string
+	}
+	l.Infow("test redact nested",
+		"map", map[string]interface{}{"token": "secret-map-token", "inner": map[string]string{"Password": "secret-inner"}},
+		"struct", account{Name: "abc", Password: "secret-struct", Mobiles: []string{"13712345678"}},
+		"object", tRedactObject{},
+		"count", 100,
+	)
+	_ = l.Close()
+
+	data, err := ioutil.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	for _, secret := range []string{"secret-map-token", "secret-inner", "secret-struct", "secret-object-token", "13812345678", "13912345678", "13712345678"} {
+		if strings.Contains(content, secret) {
+			t.Error("not redacted", secret, content)
+		}
+	}
+	line := readJSONLines(t, logPath)[0]
+	if line["struct"].(map[string]interface{})["name"] != "abc" || line["count"] != float64(100) {
+		t.Error(line)
+	}
+}
+
+func TestRedactSampling(t *testing.T) {
+	logPath := filepath.Join(tempLogDir(t), "redact.log")
+	l := NewWithOptions(Options{
+		Path:            logPath,
+		Level:           zapcore.InfoLevel,
+		Format:          FormatJSON,
+		DisableIdentity: true,
+		Redact:          &RedactOptions{Patterns: []string{`1[3-9]\d{9}`}},
+		Sampling:        &SamplingOptions{Tick: time.Hour, First: 1, Thereafter: 100},
+		SummaryInterval: time.Hour,
+	})
+	for i := 0; i < 5; i++ {
+		l.Info("test redact sampling 13812345678")
+	}
+	_ = l.Close()
+
+	data, err := ioutil.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "13812345678") {
+		t.Error("not redacted", string(data))
+	}
+	lines := readJSONLines(t, logPath)
+	if len(lines) != 2 {
+		t.Fatal("lines", lines)
+	}
+	if sampled, ok := lines[1]["sampled"].(map[string]interface{}); !ok || sampled["test redact sampling ******"] != float64(4) {
+		t.Error("summary sampled", lines[1])
+	}
+}
@@ -0,0 +1,167 @@
+package logger
+
+/**
+ * 日志文件切割
+ *
+ * 按大小切割由lumberjack完成，按时间切割(hourly | daily)在写入时检查时间周期是否变化
+ * 备份文件的压缩和清理在lumberjack的后台协程中进行，不会阻塞写日志
+ * 收到SIGHUP时关闭日志文件，下次写入时重新打开，用于配合logrotate等外部工具
+ */
+
+import (
+	"github.com/natefinch/lumberjack"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+//按时间切割的周期
+const (
+	RotateHourly = "hourly"
+	RotateDaily  = "daily"
+)
+
+//日志切割策略
+type RotateOptions struct {
+	MaxSize        int    //单个日志文件的大小，单位MB，为0时为1024
+	MaxBackups     int    //日志文件最多保存备份，为0时为30
+	MaxAge         int    //日志文件最多保存多少天，为0时为7
+	Compress       bool   //是否使用gzip压缩备份
+	Interval       string //按时间切割：hourly | daily，为空时只按大小切割
+	ReopenOnSIGHUP bool   //收到SIGHUP时重新打开日志文件
+}
+
+type rotateWriter struct {
+	logger   *lumberjack.Logger
+	interval string
+	period   string //当前的时间周期
+	lock     *sync.Mutex
+	now      func() time.Time
+}
+
+func newRotateWriter(path string, opts RotateOptions) *rotateWriter {
+	if opts.MaxSize == 0 {
+		opts.MaxSize = 1024
+	}
+	if opts.MaxBackups == 0 {
+		opts.MaxBackups = 30
+	}
+	if opts.MaxAge == 0 {
+		opts.MaxAge = 7
+	}
+
+	rtn := &rotateWriter{
+		logger: &lumberjack.Logger{
+			Filename:   path,            //日志路径
+			MaxSize:    opts.MaxSize,    //日志大小，单位MB
+			MaxBackups: opts.MaxBackups, //日志文件最多保存备份
+			MaxAge:     opts.MaxAge,     //日志文件最多保存多少天
+			LocalTime:  true,            //打印本地时间
+			Compress:   opts.Compress,   //日志备份是否压缩
+		},
+		interval: opts.Interval,
+		lock:     new(sync.Mutex),
+		now:      time.Now,
+	}
+
+	//已存在的日志文件属于其修改时间所在的周期
+	if info, err := os.Stat(path); err == nil {
+		rtn.period = rtn.periodOf(info.ModTime())
+	}
+
+	return rtn
+}
+
+//时间所在的周期
+func (rw *rotateWriter) periodOf(t time.Time) string {
+	switch rw.interval {
+	case RotateHourly:
+		return t.Format("2006010215")
+	case RotateDaily:
+		return t.Format("20060102")
+	default:
+		return ""
+	}
+}
+
+func (rw *rotateWriter) Write(p []byte) (int, error) {
+	if rw.interval != "" {
+		period := rw.periodOf(rw.now())
+		rw.lock.Lock()
+		if rw.period == "" {
+			rw.period = period
+		} else if rw.period != period {
+			rw.period = period
+			_ = rw.logger.Rotate()
+		}
+		rw.lock.Unlock()
+	}
+	return rw.logger.Write(p)
+}
+
+//切割日志文件
+func (rw *rotateWriter) Rotate() error {
+	return rw.logger.Rotate()
+}
+
+//关闭日志文件，下次写入时重新打开
+func (rw *rotateWriter) Reopen() error {
+	return rw.logger.Close()
+}
+
+//切割日志文件，只对写入文件的日志有效
+func (l *Logger) Rotate() error {
+	for _, rw := range l.rotators {
+		if err := rw.Rotate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//关闭日志文件，下次写入时重新打开，只对写入文件的日志有效
+//用于外部工具移动日志文件之后，如：logrotate的postrotate
+func (l *Logger) Reopen() error {
+	for _, rw := range l.rotators {
+		if err := rw.Reopen(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//监听SIGHUP的协程，Close时停止
+type sighupListener struct {
+	sigChan chan os.Signal
+	once    *sync.Once
+	done    chan struct{}
+}
+
+func (sl *sighupListener) stop() {
+	sl.once.Do(func() {
+		signal.Stop(sl.sigChan)
+		close(sl.done)
+	})
+}
+
+//收到SIGHUP时重新打开日志文件
+func (l *Logger) reopenOnSIGHUP() {
+	l.sighup = &sighupListener{
+		sigChan: make(chan os.Signal, 1),
+		once:    new(sync.Once),
+		done:    make(chan struct{}),
+	}
+	signal.Notify(l.sighup.sigChan, syscall.SIGHUP)
+	go func(sl *sighupListener) {
+		for {
+			select {
+			case <-sl.sigChan:
+				_ = l.Reopen()
+			case <-sl.done:
+				return
+			}
+		}
+	}(l.sighup)
+}
@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"go.uber.org/zap/zapcore"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func tempLogDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func countFiles(t *testing.T, dir string) int {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(files)
+}
+
+func TestRotate(t *testing.T) {
+	dir := tempLogDir(t)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	l := NewWithOptions(Options{
+		Path:   filepath.Join(dir, "test.log"),
+		Level:  zapcore.DebugLevel,
+		Rotate: RotateOptions{MaxSize: 1, MaxBackups: 2},
+	})
+	l.Info("test rotate")
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("test rotate")
+
+	if n := countFiles(t, dir); n != 2 {
+		t.Error("rotate files", n)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	dir := tempLogDir(t)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	path := filepath.Join(dir, "test.log")
+	l := NewWithOptions(Options{Path: path, Level: zapcore.DebugLevel})
+	l.Info("test reopen")
+
+	//模拟logrotate移动日志文件
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("test reopen")
+
+	if _, err := os.Stat(path); err != nil {
+		t.Error("reopen", err)
+	}
+}
+
+func TestRotateInterval(t *testing.T) {
+	dir := tempLogDir(t)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	now := time.Date(2019, 10, 1, 10, 30, 0, 0, time.Local)
+	rw := newRotateWriter(filepath.Join(dir, "test.log"), RotateOptions{Interval: RotateHourly})
+	rw.now = func() time.Time {
+		return now
+	}
+
+	_, _ = rw.Write([]byte("10:30\n"))
+	now = now.Add(time.Minute * 20)
+	_, _ = rw.Write([]byte("10:50\n"))
+	if n := countFiles(t, dir); n != 1 {
+		t.Error("same period", n)
+	}
+
+	now = now.Add(time.Minute * 20)
+	_, _ = rw.Write([]byte("11:10\n"))
+	if n := countFiles(t, dir); n != 2 {
+		t.Error("next period", n)
+	}
+	_ = rw.logger.Close()
+}
+
+func TestReopenOnSIGHUP(t *testing.T) {
+	dir := tempLogDir(t)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	//测试期间保持监听SIGHUP，避免Logger停止监听后进程被信号终止
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+	defer signal.Stop(sigChan)
+
+	path := filepath.Join(dir, "test.log")
+	l := NewWithOptions(Options{Path: path, Level: zapcore.DebugLevel, Rotate: RotateOptions{ReopenOnSIGHUP: true}})
+	l.Info("test sighup")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+
+	//收到信号后异步重新打开
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		l.Info("test sighup")
+		if _, err := os.Stat(path); err == nil {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatal("reopen on SIGHUP", err)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	_ = l.Close()
+	deadline = time.Now().Add(time.Second * 5)
+	for {
+		buf := make([]byte, 1<<20)
+		stack := string(buf[:runtime.Stack(buf, true)])
+		if !strings.Contains(stack, "reopenOnSIGHUP") {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatal("goroutine leak", stack)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
@@ -0,0 +1,248 @@
+package logger
+
+/**
+ * 日志采样和限流
+ *
+ * 采样：使用zap的sampler，每个Tick内相同等级和内容的日志只写入前First条，之后每Thereafter条写入1条
+ * 限流：Limit派生出的Logger按key共享一个令牌桶，令牌不足时丢弃日志
+ * 被丢弃的日志按内容(采样)或key(限流)计数，每SummaryInterval输出一条汇总日志
+ */
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/time/rate"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//日志采样配置
+type SamplingOptions struct {
+	Tick       time.Duration //采样周期，为0时为1秒
+	First      int           //每个周期内相同日志写入的条数，为0时为100
+	Thereafter int           //超过First之后每Thereafter条写入1条，为0时为100
+}
+
+//被丢弃日志的计数，由Logger及其派生的Logger共享
+type suppressor struct {
+	lock     *sync.Mutex
+	interval time.Duration
+	sampled  map[string]uint64 //按日志内容计数
+	limited  map[string]uint64 //按限流的key计数
+	total    uint64
+	limiters map[string]*rate.Limiter
+	redactor *redactor //不为nil时计数的key为脱敏后的内容，避免汇总日志泄露敏感信息
+	report   func(sampled, limited map[string]uint64)
+	started  bool //汇总协程是否已启动
+	closed   bool
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func newSuppressor(interval time.Duration, report func(sampled, limited map[string]uint64)) *suppressor {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &suppressor{
+		lock:     new(sync.Mutex),
+		interval: interval,
+		sampled:  make(map[string]uint64),
+		limited:  make(map[string]uint64),
+		limiters: make(map[string]*rate.Limiter),
+		report:   report,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+//记录被丢弃的日志，sampled为true时key为日志内容，否则为限流的key
+//第一次调用时启动输出汇总的协程，close之后调用无效
+func (s *suppressor) add(sampled bool, key string) {
+	if s.redactor != nil {
+		key = s.redactor.redactString(key)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return
+	}
+	atomic.AddUint64(&s.total, 1)
+	if sampled {
+		s.sampled[key]++
+	} else {
+		s.limited[key]++
+	}
+	if !s.started {
+		s.started = true
+		go s.run()
+	}
+}
+
+func (s *suppressor) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.stop:
+			s.flush()
+			return
+		}
+	}
+}
+
+//输出并清空计数
+func (s *suppressor) flush() {
+	s.lock.Lock()
+	sampled, limited := s.sampled, s.limited
+	s.sampled = make(map[string]uint64)
+	s.limited = make(map[string]uint64)
+	s.lock.Unlock()
+
+	if len(sampled) != 0 || len(limited) != 0 {
+		s.report(sampled, limited)
+	}
+}
+
+//停止汇总协程，并输出剩余的计数，可以重复调用
+func (s *suppressor) close() {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return
+	}
+	s.closed = true
+	started := s.started
+	s.lock.Unlock()
+
+	if started {
+		close(s.stop)
+		<-s.done
+	}
+}
+
+//获取key对应的令牌桶，已存在并且b相同时更新速率，b不同时新建令牌桶
+func (s *suppressor) limiter(key string, r rate.Limit, b int) *rate.Limiter {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if limiter, ok := s.limiters[key]; ok && limiter.Burst() == b {
+		limiter.SetLimit(r)
+		return limiter
+	}
+	limiter := rate.NewLimiter(r, b)
+	s.limiters[key] = limiter
+	return limiter
+}
+
+//使用zap的sampler采样，并记录被丢弃的日志
+type sampleCore struct {
+	zapcore.Core
+	suppressor *suppressor
+}
+
+func newSampleCore(core zapcore.Core, opts SamplingOptions, s *suppressor) zapcore.Core {
+	if opts.Tick <= 0 {
+		opts.Tick = time.Second
+	}
+	if opts.First <= 0 {
+		opts.First = 100
+	}
+	if opts.Thereafter <= 0 {
+		opts.Thereafter = 100
+	}
+	return &sampleCore{
+		Core:       zapcore.NewSampler(core, opts.Tick, opts.First, opts.Thereafter),
+		suppressor: s,
+	}
+}
+
+func (sc *sampleCore) With(fields []zapcore.Field) zapcore.Core {
+	return &sampleCore{
+		Core:       sc.Core.With(fields),
+		suppressor: sc.suppressor,
+	}
+}
+
+func (sc *sampleCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !sc.Enabled(ent.Level) {
+		return ce
+	}
+	rtn := sc.Core.Check(ent, ce)
+	//zap.Logger传入的ce为nil，sampler丢弃日志时返回nil
+	if ce == nil && rtn == nil {
+		sc.suppressor.add(true, ent.Message)
+	}
+	return rtn
+}
+
+//按key限流
+type limitCore struct {
+	zapcore.Core
+	key        string
+	limiter    *rate.Limiter
+	suppressor *suppressor
+}
+
+func (lc *limitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &limitCore{
+		Core:       lc.Core.With(fields),
+		key:        lc.key,
+		limiter:    lc.limiter,
+		suppressor: lc.suppressor,
+	}
+}
+
+func (lc *limitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !lc.Enabled(ent.Level) {
+		return ce
+	}
+	if !lc.limiter.Allow() {
+		lc.suppressor.add(false, lc.key)
+		return ce
+	}
+	return lc.Core.Check(ent, ce)
+}
+
+//派生出按key限流的Logger，每秒最多r条，突发最多b条，相同key的Logger共享令牌桶
+//如：l.Limit("kafka_retry", 1, 10).Warn("retry")
+func (l *Logger) Limit(key string, r rate.Limit, b int) *Logger {
+	limiter := l.suppressor.limiter(key, r, b)
+	return l.derive(l.logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &limitCore{
+			Core:       core,
+			key:        key,
+			limiter:    limiter,
+			suppressor: l.suppressor,
+		}
+	})))
+}
+
+//因采样和限流而丢弃的日志条数
+func (l *Logger) Suppressed() uint64 {
+	return atomic.LoadUint64(&l.suppressor.total)
+}
+
+//输出被丢弃日志的汇总
+func (l *Logger) reportSuppressed(sampled, limited map[string]uint64) {
+	total := uint64(0)
+	fields := make([]zap.Field, 0, 3)
+	if len(sampled) != 0 {
+		for _, n := range sampled {
+			total += n
+		}
+		fields = append(fields, zap.Any("sampled", sampled))
+	}
+	if len(limited) != 0 {
+		for _, n := range limited {
+			total += n
+		}
+		fields = append(fields, zap.Any("limited", limited))
+	}
+	fields = append(fields, zap.Uint64("suppressed", total))
+	l.logger.Warn("log entries suppressed", fields...)
+}
@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"encoding/json"
+	"go.uber.org/zap/zapcore"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+//读取json格式的日志
+func readJSONLines(t *testing.T, path string) []map[string]interface{} {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rtn := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		m := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatal(err, line)
+		}
+		rtn = append(rtn, m)
+	}
+	return rtn
+}
+
+func TestSampling(t *testing.T) {
+	logPath := filepath.Join(tempLogDir(t), "sample.log")
+	l := NewWithOptions(Options{
+		Path:            logPath,
+		Level:           zapcore.InfoLevel,
+		Format:          FormatJSON,
+		DisableIdentity: true,
+		Sampling:        &SamplingOptions{Tick: time.Hour, First: 3, Thereafter: 10},
+		SummaryInterval: time.Hour,
+	})
+
+	for i := 0; i < 25; i++ {
+		l.Info("test sampling hot")
+	}
+	l.Info("test sampling other")
+	//第1、2、3、13、23条被写入
+	if l.Suppressed() != 20 {
+		t.Error("suppressed", l.Suppressed())
+	}
+	_ = l.Close()
+
+	lines := readJSONLines(t, logPath)
+	if len(lines) != 7 {
+		t.Fatal("lines", len(lines))
+	}
+	summary := lines[6]
+	if summary["msg"] != "log entries suppressed" || summary["suppressed"] != float64(20) {
+		t.Error("summary", summary)
+	}
+	if sampled, ok := summary["sampled"].(map[string]interface{}); !ok || sampled["test sampling hot"] != float64(20) {
+		t.Error("summary sampled", summary["sampled"])
+	}
+}
+
+func TestLimit(t *testing.T) {
+	logPath := filepath.Join(tempLogDir(t), "limit.log")
+	l := NewWithOptions(Options{
+		Path:            logPath,
+		Level:           zapcore.InfoLevel,
+		Format:          FormatJSON,
+		DisableIdentity: true,
+		SummaryInterval: time.Hour,
+	})
+
+	for i := 0; i < 10; i++ {
+		//相同key的Logger共享令牌桶
+		l.Limit("test_key", 0.001, 2).With("i", i).Info("test limit")
+	}
+	l.Debug("test limit debug")
+	if l.Suppressed() != 8 {
+		t.Error("suppressed", l.Suppressed())
+	}
+	_ = l.Close()
+
+	lines := readJSONLines(t, logPath)
+	if len(lines) != 3 {
+		t.Fatal("lines", len(lines))
+	}
+	if limited, ok := lines[2]["limited"].(map[string]interface{}); !ok || limited["test_key"] != float64(8) {
+		t.Error("summary limited", lines[2])
+	}
+}
+
+func TestSummaryInterval(t *testing.T) {
+	count := 0
+	s := newSuppressor(time.Millisecond*10, func(sampled, limited map[string]uint64) {
+		count++
+	})
+	s.add(true, "test summary")
+	time.Sleep(time.Millisecond * 50)
+	s.close()
+	s.close()
+	if count != 1 {
+		t.Error("summary count", count)
+	}
+}
+
+func TestDoubleClose(t *testing.T) {
+	logPath := filepath.Join(tempLogDir(t), "close.log")
+	l := NewWithOptions(Options{Path: logPath, Level: zapcore.InfoLevel, Format: FormatJSON, DisableIdentity: true})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		//没有丢弃过日志时，重复Close和派生的Logger的Close都不能阻塞
+		_ = l.Close()
+		_ = l.Close()
+		_ = l.With("k", "v").Close()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 3):
+		t.Fatal("close blocked")
+	}
+
+	//close之后不再计数，也不启动汇总协程
+	l.suppressor.add(true, "test close")
+	if l.Suppressed() != 0 || l.suppressor.started {
+		t.Error("suppressed", l.Suppressed())
+	}
+}
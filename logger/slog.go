@@ -0,0 +1,126 @@
+//go:build go1.21
+// +build go1.21
+
+package logger
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"log/slog"
+	"runtime"
+)
+
+//slog.Handler，如：slog.SetDefault(slog.New(l.SlogHandler()))
+//slog的等级映射为：低于info为debug，低于warn为info，低于error为warn，其余为error
+type SlogHandler struct {
+	logger *zap.Logger
+}
+
+func (l *Logger) SlogHandler() *SlogHandler {
+	return &SlogHandler{logger: l.logger}
+}
+
+func slogLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+func (sh *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return sh.logger.Core().Enabled(slogLevel(level))
+}
+
+func (sh *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	ce := sh.logger.Check(slogLevel(record.Level), record.Message)
+	if ce == nil {
+		return nil
+	}
+	if !record.Time.IsZero() {
+		ce.Entry.Time = record.Time
+	}
+	//调用位置使用slog记录的pc
+	if ce.Entry.Caller.Defined && record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		ce.Entry.Caller = zapcore.NewEntryCaller(record.PC, frame.File, frame.Line, true)
+	}
+
+	fields := make([]zapcore.Field, 0, record.NumAttrs()+2)
+	if trace, ok := TraceFromContext(ctx); ok {
+		fields = append(fields, zap.String(TraceIdKey, trace.TraceId), zap.String(SpanIdKey, trace.SpanId))
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendSlogAttr(fields, attr)
+		return true
+	})
+	ce.Write(fields...)
+	return nil
+}
+
+func (sh *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SlogHandler{logger: sh.logger.With(appendSlogAttrs(attrs)...)}
+}
+
+//之后的字段都放在name之下，如：{"name": {"k1": "v1"}}
+func (sh *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return sh
+	}
+	return &SlogHandler{logger: sh.logger.With(zap.Namespace(name))}
+}
+
+//把slog.Attr转换为zap的字段，忽略空的Attr，key为空的group展开到上一层
+func appendSlogAttr(fields []zapcore.Field, attr slog.Attr) []zapcore.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(attr.Key, attr.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(attr.Key, attr.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(attr.Key, attr.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(attr.Key, attr.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(attr.Key, attr.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(attr.Key, attr.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(attr.Key, attr.Value.Time()))
+	case slog.KindGroup:
+		group := attr.Value.Group()
+		if attr.Key == "" {
+			for _, a := range group {
+				fields = appendSlogAttr(fields, a)
+			}
+			return fields
+		}
+		return append(fields, zap.Object(attr.Key, zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			for _, f := range appendSlogAttrs(group) {
+				f.AddTo(enc)
+			}
+			return nil
+		})))
+	default:
+		return append(fields, zap.Any(attr.Key, attr.Value.Any()))
+	}
+}
+
+func appendSlogAttrs(attrs []slog.Attr) []zapcore.Field {
+	rtn := make([]zapcore.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		rtn = appendSlogAttr(rtn, attr)
+	}
+	return rtn
+}
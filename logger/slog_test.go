@@ -0,0 +1,32 @@
+//go:build go1.21
+// +build go1.21
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	l, read := tAdapterLogger(t)
+	sl := slog.New(l.SlogHandler())
+	ctx := ContextWithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	sl.Debug("test slog debug")
+	sl.With("k1", "v1").WithGroup("req").InfoContext(ctx, "test slog info", "id", 1, slog.Group("user", "name", "abc"))
+	sl.Log(ctx, slog.LevelWarn+1, "test slog warn")
+	sl.Error("test slog error", slog.Group("", "inline", true))
+
+	lines := read()
+	tCheckLines(t, lines, []string{"debug", "info", "warn", "error"}, "logger/slog_test.go")
+	req, _ := lines[1]["req"].(map[string]interface{})
+	user, _ := req["user"].(map[string]interface{})
+	if lines[1]["k1"] != "v1" || req["id"] != float64(1) || user["name"] != "abc" || req[TraceIdKey] == nil {
+		t.Error("attrs", lines[1])
+	}
+	if lines[3]["inline"] != true {
+		t.Error("inline group", lines[3])
+	}
+}
@@ -0,0 +1,309 @@
+package logger
+
+/**
+ * 日志关联trace
+ *
+ * 从W3C traceparent中解析trace_id和span_id并存入context，格式为：
+ * {version}-{trace_id}-{parent_id}-{flags}，如：00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+ * XxxCtx方法从context中获取trace，在日志中添加trace_id和span_id字段
+ * TraceMiddleware从http请求头的traceparent中解析trace并存入请求的context
+ */
+
+import (
+	"context"
+	"encoding/hex"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+//日志中trace的字段名
+const (
+	TraceIdKey = "trace_id"
+	SpanIdKey  = "span_id"
+)
+
+//http请求头中的traceparent
+const TraceparentHeader = "traceparent"
+
+type Trace struct {
+	TraceId string //32位16进制
+	SpanId  string //16位16进制
+	Sampled bool
+}
+
+type traceKey struct{}
+
+//解析traceparent，格式不合法时返回false
+func ParseTraceparent(traceparent string) (Trace, bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 {
+		return Trace{}, false
+	}
+	version, traceId, spanId, flags := parts[0], parts[1], parts[2], parts[3]
+	//版本00只有4个部分，更高的版本可能在后面追加字段
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return Trace{}, false
+	}
+	if !isHex(traceId, 32) || traceId == strings.Repeat("0", 32) {
+		return Trace{}, false
+	}
+	if !isHex(spanId, 16) || spanId == strings.Repeat("0", 16) {
+		return Trace{}, false
+	}
+	if !isHex(flags, 2) {
+		return Trace{}, false
+	}
+
+	flag, _ := hex.DecodeString(flags)
+	return Trace{
+		TraceId: traceId,
+		SpanId:  spanId,
+		Sampled: flag[0]&0x01 == 0x01,
+	}, true
+}
+
+//是否为长度为n的小写16进制字符串
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+//把trace存入context
+func ContextWithTrace(ctx context.Context, trace Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+//解析traceparent并存入context，格式不合法时返回原context
+func ContextWithTraceparent(ctx context.Context, traceparent string) context.Context {
+	if trace, ok := ParseTraceparent(traceparent); ok {
+		return ContextWithTrace(ctx, trace)
+	}
+	return ctx
+}
+
+//从context中获取trace，不存在时返回false
+func TraceFromContext(ctx context.Context) (Trace, bool) {
+	if ctx == nil {
+		return Trace{}, false
+	}
+	trace, ok := ctx.Value(traceKey{}).(Trace)
+	return trace, ok
+}
+
+//从请求头的traceparent中解析trace并存入请求的context
+func TraceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if traceparent := r.Header.Get(TraceparentHeader); traceparent != "" {
+			r = r.WithContext(ContextWithTraceparent(r.Context(), traceparent))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+//trace对应的日志字段
+func traceFields(ctx context.Context) []interface{} {
+	trace, ok := TraceFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return []interface{}{zap.String(TraceIdKey, trace.TraceId), zap.String(SpanIdKey, trace.SpanId)}
+}
+
+//派生出带有context中trace字段的Logger，context中没有trace时返回l
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	if fields := traceFields(ctx); fields != nil {
+		return l.With(fields...)
+	}
+	return l
+}
+
+//带有context中trace字段的sugar
+func (l *Logger) ctxSugar(ctx context.Context) *zap.SugaredLogger {
+	if fields := traceFields(ctx); fields != nil {
+		return l.sugar.With(fields...)
+	}
+	return l.sugar
+}
+
+//派生出带有context中trace字段的默认Logger
+func WithContext(ctx context.Context) *Logger {
+	return Default().WithContext(ctx)
+}
+
+func (l *Logger) DebugCtx(ctx context.Context, args ...interface{}) {
+	l.ctxSugar(ctx).Debug(args...)
+}
+
+func (l *Logger) InfoCtx(ctx context.Context, args ...interface{}) {
+	l.ctxSugar(ctx).Info(args...)
+}
+
+func (l *Logger) WarnCtx(ctx context.Context, args ...interface{}) {
+	l.ctxSugar(ctx).Warn(args...)
+}
+
+func (l *Logger) ErrorCtx(ctx context.Context, args ...interface{}) {
+	l.ctxSugar(ctx).Error(args...)
+}
+
+func (l *Logger) DPanicCtx(ctx context.Context, args ...interface{}) {
+	l.ctxSugar(ctx).DPanic(args...)
+}
+
+func (l *Logger) PanicCtx(ctx context.Context, args ...interface{}) {
+	l.ctxSugar(ctx).Panic(args...)
+}
+
+func (l *Logger) FatalCtx(ctx context.Context, args ...interface{}) {
+	l.ctxSugar(ctx).Fatal(args...)
+}
+
+func (l *Logger) DebugfCtx(ctx context.Context, template string, args ...interface{}) {
+	l.ctxSugar(ctx).Debugf(template, args...)
+}
+
+func (l *Logger) InfofCtx(ctx context.Context, template string, args ...interface{}) {
+	l.ctxSugar(ctx).Infof(template, args...)
+}
+
+func (l *Logger) WarnfCtx(ctx context.Context, template string, args ...interface{}) {
+	l.ctxSugar(ctx).Warnf(template, args...)
+}
+
+func (l *Logger) ErrorfCtx(ctx context.Context, template string, args ...interface{}) {
+	l.ctxSugar(ctx).Errorf(template, args...)
+}
+
+func (l *Logger) DPanicfCtx(ctx context.Context, template string, args ...interface{}) {
+	l.ctxSugar(ctx).DPanicf(template, args...)
+}
+
+func (l *Logger) PanicfCtx(ctx context.Context, template string, args ...interface{}) {
+	l.ctxSugar(ctx).Panicf(template, args...)
+}
+
+func (l *Logger) FatalfCtx(ctx context.Context, template string, args ...interface{}) {
+	l.ctxSugar(ctx).Fatalf(template, args...)
+}
+
+func (l *Logger) DebugwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.ctxSugar(ctx).Debugw(msg, keysAndValues...)
+}
+
+func (l *Logger) InfowCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.ctxSugar(ctx).Infow(msg, keysAndValues...)
+}
+
+func (l *Logger) WarnwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.ctxSugar(ctx).Warnw(msg, keysAndValues...)
+}
+
+func (l *Logger) ErrorwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.ctxSugar(ctx).Errorw(msg, keysAndValues...)
+}
+
+func (l *Logger) DPanicwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.ctxSugar(ctx).DPanicw(msg, keysAndValues...)
+}
+
+func (l *Logger) PanicwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.ctxSugar(ctx).Panicw(msg, keysAndValues...)
+}
+
+func (l *Logger) FatalwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.ctxSugar(ctx).Fatalw(msg, keysAndValues...)
+}
+
+//以下函数使用默认的Logger
+
+func DebugCtx(ctx context.Context, args ...interface{}) {
+	Default().ctxSugar(ctx).Debug(args...)
+}
+
+func InfoCtx(ctx context.Context, args ...interface{}) {
+	Default().ctxSugar(ctx).Info(args...)
+}
+
+func WarnCtx(ctx context.Context, args ...interface{}) {
+	Default().ctxSugar(ctx).Warn(args...)
+}
+
+func ErrorCtx(ctx context.Context, args ...interface{}) {
+	Default().ctxSugar(ctx).Error(args...)
+}
+
+func DPanicCtx(ctx context.Context, args ...interface{}) {
+	Default().ctxSugar(ctx).DPanic(args...)
+}
+
+func PanicCtx(ctx context.Context, args ...interface{}) {
+	Default().ctxSugar(ctx).Panic(args...)
+}
+
+func FatalCtx(ctx context.Context, args ...interface{}) {
+	Default().ctxSugar(ctx).Fatal(args...)
+}
+
+func DebugfCtx(ctx context.Context, template string, args ...interface{}) {
+	Default().ctxSugar(ctx).Debugf(template, args...)
+}
+
+func InfofCtx(ctx context.Context, template string, args ...interface{}) {
+	Default().ctxSugar(ctx).Infof(template, args...)
+}
+
+func WarnfCtx(ctx context.Context, template string, args ...interface{}) {
+	Default().ctxSugar(ctx).Warnf(template, args...)
+}
+
+func ErrorfCtx(ctx context.Context, template string, args ...interface{}) {
+	Default().ctxSugar(ctx).Errorf(template, args...)
+}
+
+func DPanicfCtx(ctx context.Context, template string, args ...interface{}) {
+	Default().ctxSugar(ctx).DPanicf(template, args...)
+}
+
+func PanicfCtx(ctx context.Context, template string, args ...interface{}) {
+	Default().ctxSugar(ctx).Panicf(template, args...)
+}
+
+func FatalfCtx(ctx context.Context, template string, args ...interface{}) {
+	Default().ctxSugar(ctx).Fatalf(template, args...)
+}
+
+func DebugwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	Default().ctxSugar(ctx).Debugw(msg, keysAndValues...)
+}
+
+func InfowCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	Default().ctxSugar(ctx).Infow(msg, keysAndValues...)
+}
+
+func WarnwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	Default().ctxSugar(ctx).Warnw(msg, keysAndValues...)
+}
+
+func ErrorwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	Default().ctxSugar(ctx).Errorw(msg, keysAndValues...)
+}
+
+func DPanicwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	Default().ctxSugar(ctx).DPanicw(msg, keysAndValues...)
+}
+
+func PanicwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	Default().ctxSugar(ctx).Panicw(msg, keysAndValues...)
+}
+
+func FatalwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	Default().ctxSugar(ctx).Fatalw(msg, keysAndValues...)
+}
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"context"
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	testCase := map[string]bool{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":     true,
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-xyz": true,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-xyz": false,
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":     false,
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01":     false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01":     false,
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01":     false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7":        false,
+		"": false,
+	}
+	for traceparent, expect := range testCase {
+		if _, ok := ParseTraceparent(traceparent); ok != expect {
+			t.Error(traceparent, expect)
+		}
+	}
+
+	trace, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if trace.TraceId != "4bf92f3577b34da6a3ce929d0e0e4736" || trace.SpanId != "00f067aa0ba902b7" || !trace.Sampled {
+		t.Error("parse", trace)
+	}
+}
+
+func TestLogCtx(t *testing.T) {
+	logPath := filepath.Join(tempLogDir(t), "trace.log")
+	l := NewWithOptions(Options{Path: logPath, Level: zapcore.InfoLevel, Format: FormatJSON, DisableIdentity: true})
+
+	ctx := ContextWithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	l.InfoCtx(ctx, "test trace info")
+	l.WarnwCtx(ctx, "test trace warn", "k1", "v1")
+	l.InfofCtx(context.Background(), "test trace %s", "none")
+	l.WithContext(ctx).Error("test trace with context")
+	_ = l.Close()
+
+	lines := readJSONLines(t, logPath)
+	if len(lines) != 4 {
+		t.Fatal("lines", len(lines))
+	}
+	for i, line := range lines {
+		expect := "4bf92f3577b34da6a3ce929d0e0e4736"
+		if i == 2 {
+			expect = ""
+		}
+		if traceId, _ := line[TraceIdKey].(string); traceId != expect {
+			t.Error("trace_id", line)
+		}
+	}
+	if lines[1]["span_id"] != "00f067aa0ba902b7" || lines[1]["k1"] != "v1" {
+		t.Error("span_id", lines[1])
+	}
+}
+
+func TestTraceMiddleware(t *testing.T) {
+	var trace Trace
+	handler := TraceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace, _ = TraceFromContext(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	if trace.TraceId != "4bf92f3577b34da6a3ce929d0e0e4736" || trace.Sampled {
+		t.Error("middleware", trace)
+	}
+}
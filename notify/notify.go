@@ -79,12 +79,12 @@ func (n *Notify) isIgnore(body string) bool {
 	}
 	//字符串匹配 + 最小值
 	n.limitMapLock.RLock()
+	defer n.limitMapLock.RUnlock()
 	for sub, l := range n.limitMap {
 		if strings.Contains(body, sub) && !l.Allow() {
 			return true
 		}
 	}
-	n.limitMapLock.RUnlock()
 
 	return false
 }
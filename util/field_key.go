@@ -0,0 +1,44 @@
+package util
+
+import (
+	"reflect"
+	"unicode"
+)
+
+//结构体字段对应的key，args.Bind和conf.Unmarshal共用同一套规则：
+//name为tag中的名字，"-"时忽略该字段，为空时使用字段名的蛇形命名
+//没有name的匿名结构体字段展开到上一层，此时inline为true
+//忽略未导出的字段，但未导出的匿名结构体中导出的字段仍然有效
+func FieldKey(field reflect.StructField, name string) (key string, inline bool, ok bool) {
+	if name == "-" {
+		return "", false, false
+	}
+	isStruct := field.Type.Kind() == reflect.Struct && !IsTextUnmarshaler(field.Type)
+	if field.PkgPath != "" && !(field.Anonymous && isStruct) {
+		return "", false, false
+	}
+	if isStruct && field.Anonymous && name == "" {
+		return "", true, true
+	}
+	if name == "" {
+		name = SnakeCase(field.Name)
+	}
+	return name, false, true
+}
+
+//驼峰命名转换为蛇形命名，如：LogPath -> log_path，HTTPPort -> http_port
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	rtn := make([]rune, 0, len(runes)+4)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				rtn = append(rtn, '_')
+			}
+			r = unicode.ToLower(r)
+		}
+		rtn = append(rtn, r)
+	}
+	return string(rtn)
+}
@@ -0,0 +1,129 @@
+package util
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//SetValue不支持的类型，调用方可以用json等方式继续解析
+var ErrUnsupportedType = errors.New("unsupported type")
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+//类型是否实现了encoding.TextUnmarshaler(指针接收者)
+func IsTextUnmarshaler(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+//把字符串转换成rv的类型并赋值，rv必须可以寻址
+//支持encoding.TextUnmarshaler、time.Duration、string、bool、int、uint、float和[]string(按逗号分隔)
+//整数为十进制，"0x"开头时为十六进制，如："010"为10，"0x10"为16
+//除string外会去掉value两端的空白，其他类型返回ErrUnsupportedType
+func SetValue(rv reflect.Value, value string) error {
+	if u, ok := rv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(strings.TrimSpace(value)))
+	}
+	if rv.Kind() != reflect.String {
+		value = strings.TrimSpace(value)
+	}
+	if rv.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		rv.SetInt(int64(d))
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := ParseInt(value, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := ParseUint(value, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(f)
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("%s: %w", rv.Type(), ErrUnsupportedType)
+		}
+		SetStringSlice(rv, SplitList(value))
+	default:
+		return fmt.Errorf("%s: %w", rv.Type(), ErrUnsupportedType)
+	}
+	return nil
+}
+
+//把list逐个赋值给元素类型为string的切片，元素可以是自定义的string类型，如：[]Region
+func SetStringSlice(rv reflect.Value, list []string) {
+	slice := reflect.MakeSlice(rv.Type(), len(list), len(list))
+	for i, item := range list {
+		slice.Index(i).SetString(item)
+	}
+	rv.Set(slice)
+}
+
+//解析整数，十进制或"0x"开头的十六进制，可以有正负号
+func ParseInt(value string, bitSize int) (int64, error) {
+	sign, digits := "", value
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		sign, digits = digits[:1], digits[1:]
+	}
+	if hex, ok := trimHexPrefix(digits); ok {
+		return strconv.ParseInt(sign+hex, 16, bitSize)
+	}
+	return strconv.ParseInt(value, 10, bitSize)
+}
+
+//解析无符号整数，十进制或"0x"开头的十六进制
+func ParseUint(value string, bitSize int) (uint64, error) {
+	if hex, ok := trimHexPrefix(value); ok {
+		return strconv.ParseUint(hex, 16, bitSize)
+	}
+	return strconv.ParseUint(value, 10, bitSize)
+}
+
+func trimHexPrefix(value string) (string, bool) {
+	if len(value) > 2 && value[0] == '0' && (value[1] == 'x' || value[1] == 'X') {
+		return value[2:], true
+	}
+	return value, false
+}
+
+//按逗号分隔列表，去掉元素两端的空白并忽略空元素
+func SplitList(value string) []string {
+	rtn := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			rtn = append(rtn, item)
+		}
+	}
+	return rtn
+}